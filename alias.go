@@ -0,0 +1,61 @@
+package featureflags
+
+import "sync/atomic"
+
+// updateAliases adds aliases sent by the server. The aliases map is shared
+// with previous states, so it is copied before modification.
+func (state *State) updateAliases(flags []FlagResponse) {
+	var aliases map[string]string
+	for _, flag := range flags {
+		for _, alias := range flag.Aliases {
+			if state.aliases[alias] == flag.Name {
+				continue
+			}
+			if aliases == nil {
+				aliases = make(map[string]string, len(state.aliases)+1)
+				for name, target := range state.aliases {
+					aliases[name] = target
+				}
+			}
+			aliases[alias] = flag.Name
+		}
+	}
+	if aliases != nil {
+		state.aliases = aliases
+	}
+}
+
+// resolveAlias returns the current name of the flag, counting lookups by an alias
+func (flags *FeatureFlags) resolveAlias(state *State, name string) string {
+	if len(state.aliases) == 0 {
+		return name
+	}
+	if target, ok := state.aliases[name]; ok {
+		flags.aliasUsed(name, target)
+		return target
+	}
+	return name
+}
+
+// aliasUsed counts a lookup by the alias and logs the first one
+func (flags *FeatureFlags) aliasUsed(alias, target string) {
+	counter, ok := flags.aliasUsage.Load(alias)
+	if !ok {
+		counter, _ = flags.aliasUsage.LoadOrStore(alias, new(atomic.Uint64))
+	}
+	if counter.(*atomic.Uint64).Add(1) == 1 {
+		flags.logger.Printf("Flag %s is renamed to %s, update the call sites", alias, target)
+	}
+}
+
+// AliasUsage returns the number of lookups by each flag alias since the client was created.
+// Aliases which were never looked up are missing, so an empty result over a representative
+// period means all call sites were migrated to the new names.
+func (flags *FeatureFlags) AliasUsage() map[string]uint64 {
+	usage := make(map[string]uint64)
+	flags.aliasUsage.Range(func(alias, counter any) bool {
+		usage[alias.(string)] = counter.(*atomic.Uint64).Load()
+		return true
+	})
+	return usage
+}
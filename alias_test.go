@@ -0,0 +1,48 @@
+package featureflags
+
+import "testing"
+
+// Test flags can be looked up by aliases declared in defaults and by the server
+func TestAliases(t *testing.T) {
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flags.state.Store(&State{
+		version: 1,
+		flagState: map[string]FlagState{
+			"new_checkout": {Name: "new_checkout", Enabled: true},
+			"new_search":   {Name: "new_search", Enabled: false},
+		},
+		flagNames:  []string{"new_checkout", "new_search"},
+		valueState: map[string]ValueState{},
+		aliases:    map[string]string{"checkout_v2": "new_checkout"},
+	})
+	previous := flags.loadState()
+
+	if !flags.Get("checkout_v2") {
+		t.Error("Expected checkout_v2 to resolve to new_checkout")
+	}
+	if _, enabled := flags.GetFlagPayload("checkout_v2"); !enabled {
+		t.Error("Expected payload of checkout_v2 to resolve to new_checkout")
+	}
+	if !flags.Flag("checkout_v2").Enabled() {
+		t.Error("Expected handle for checkout_v2 to resolve to new_checkout")
+	}
+
+	flags.update(2, []FlagResponse{{Name: "new_search", Enabled: true, Aliases: []string{"search_v2"}}}, nil)
+	if !flags.Get("search_v2") {
+		t.Error("Expected search_v2 from the server to resolve to new_search")
+	}
+	if !flags.Get("checkout_v2") {
+		t.Error("Expected aliases from defaults to be kept after update")
+	}
+	if _, exists := previous.aliases["search_v2"]; exists {
+		t.Error("Expected previous state aliases not to be modified")
+	}
+
+	usage := flags.AliasUsage()
+	if usage["checkout_v2"] != 4 || usage["search_v2"] != 1 || len(usage) != 2 {
+		t.Errorf("Unexpected alias usage: %v", usage)
+	}
+	if flags.Get("new_checkout"); len(flags.AliasUsage()) != 2 {
+		t.Error("Expected lookups by the new name not to be counted")
+	}
+}
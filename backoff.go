@@ -0,0 +1,38 @@
+package featureflags
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+const defaultBackoffMax = 5 * time.Minute
+
+// backoff computes delays between sync attempts after consecutive failures
+type backoff struct {
+	min time.Duration
+	max time.Duration
+	// rand is used for jitter, the global source is used if nil.
+	// It is only used by the sync loop goroutine, so it needs no locking.
+	rand *rand.Rand
+}
+
+// delay returns the delay after the given number of consecutive failures:
+// min doubled on every failure up to max, with jitter of up to a half of the delay,
+// so clients failing at the same time do not retry at the same time.
+func (b backoff) delay(failures int) time.Duration {
+	d := b.max
+	if failures <= 1 {
+		d = b.min
+	} else if shift := failures - 1; shift < 63 && b.min <= b.max>>shift {
+		d = b.min << shift
+	}
+	if d <= 0 {
+		return d
+	}
+
+	half := d / 2
+	if b.rand != nil {
+		return half + time.Duration(b.rand.Int64N(int64(d-half+1)))
+	}
+	return half + rand.N(d-half+1)
+}
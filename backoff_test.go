@@ -0,0 +1,68 @@
+package featureflags
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// Test backoff delays grow exponentially with jitter and are capped
+func TestBackoffDelay(t *testing.T) {
+	b := backoff{min: time.Second, max: 10 * time.Second}
+
+	tests := []struct {
+		failures int
+		base     time.Duration
+	}{
+		{failures: 1, base: time.Second},
+		{failures: 2, base: 2 * time.Second},
+		{failures: 3, base: 4 * time.Second},
+		{failures: 4, base: 8 * time.Second},
+		{failures: 5, base: 10 * time.Second},
+		{failures: 100, base: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 100 {
+			delay := b.delay(tt.failures)
+			if delay < tt.base/2 || delay > tt.base {
+				t.Fatalf("failures=%d: expected delay in [%v, %v], got %v", tt.failures, tt.base/2, tt.base, delay)
+			}
+		}
+	}
+}
+
+// Test WithBackoff configuration and defaults
+func TestWithBackoff(t *testing.T) {
+	config := &ClientConfig{}
+	WithBackoff(time.Second, time.Minute)(config)
+
+	if config.backoffMin != time.Second {
+		t.Errorf("Expected min 1s, got %v", config.backoffMin)
+	}
+	if config.backoffMax != time.Minute {
+		t.Errorf("Expected max 1m, got %v", config.backoffMax)
+	}
+}
+
+// Test backoff jitter is reproducible with the same random source
+func TestBackoffRandSource(t *testing.T) {
+	delays := func() []time.Duration {
+		config := &ClientConfig{}
+		WithRandSource(rand.NewPCG(1, 2))(config)
+		b := backoff{min: time.Second, max: time.Minute, rand: rand.New(config.randSource)}
+
+		result := make([]time.Duration, 10)
+		for i := range result {
+			result[i] = b.delay(i + 1)
+		}
+		return result
+	}
+
+	first, second := delays(), delays()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected same delays for the same seed, got %v and %v", first, second)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is a state of the circuit breaker around server requests
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota + 1 // 1, requests are allowed
+	CircuitOpen                             // 2, requests are rejected until the cool-down ends
+	CircuitHalfOpen                         // 3, a single trial request is allowed
+)
+
+func (state CircuitState) String() string {
+	switch state {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+var ErrorCircuitOpen = errors.New("circuit breaker is open")
+
+// circuitBreaker stops requests to the server for a cool-down period
+// after a number of consecutive failures
+type circuitBreaker struct {
+	threshold int
+	coolDown  time.Duration
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, coolDown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		coolDown:  coolDown,
+		state:     CircuitClosed,
+	}
+}
+
+// allow returns ErrorCircuitOpen if a request must not be sent
+func (cb *circuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.coolDown {
+			return ErrorCircuitOpen
+		}
+		// Cool-down is over, let a single trial request through
+		cb.state = CircuitHalfOpen
+		return nil
+	case CircuitHalfOpen:
+		return ErrorCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// record updates the breaker with a result of an allowed request
+func (cb *circuitBreaker) record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if err == nil {
+		cb.state = CircuitClosed
+		cb.failures = 0
+		return
+	}
+	// Cancellation is caused by the caller, not by the server
+	if errors.Is(err, context.Canceled) {
+		if cb.state == CircuitHalfOpen {
+			cb.state = CircuitOpen
+		}
+		return
+	}
+
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.threshold {
+		cb.state = CircuitOpen
+		cb.openedAt = time.Now()
+	}
+}
+
+func (cb *circuitBreaker) current() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// CircuitState returns the state of the circuit breaker. It is always CircuitClosed
+// when the circuit breaker is not enabled with WithCircuitBreaker.
+func (flags *FeatureFlags) CircuitState() CircuitState {
+	if flags.breaker == nil {
+		return CircuitClosed
+	}
+	return flags.breaker.current()
+}
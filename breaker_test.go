@@ -0,0 +1,105 @@
+package featureflags
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test circuit breaker state transitions
+func TestCircuitBreaker(t *testing.T) {
+	errServer := errors.New("server error")
+
+	t.Run("opens after threshold failures", func(t *testing.T) {
+		cb := newCircuitBreaker(2, time.Hour)
+
+		cb.record(errServer)
+		if cb.current() != CircuitClosed {
+			t.Errorf("Expected closed after 1 failure, got %v", cb.current())
+		}
+		cb.record(errServer)
+		if cb.current() != CircuitOpen {
+			t.Errorf("Expected open after 2 failures, got %v", cb.current())
+		}
+		if err := cb.allow(); !errors.Is(err, ErrorCircuitOpen) {
+			t.Errorf("Expected ErrorCircuitOpen, got %v", err)
+		}
+	})
+
+	t.Run("success resets failures", func(t *testing.T) {
+		cb := newCircuitBreaker(2, time.Hour)
+
+		cb.record(errServer)
+		cb.record(nil)
+		cb.record(errServer)
+		if cb.current() != CircuitClosed {
+			t.Errorf("Expected closed, got %v", cb.current())
+		}
+	})
+
+	t.Run("half-open after cool-down", func(t *testing.T) {
+		cb := newCircuitBreaker(1, time.Millisecond)
+
+		cb.record(errServer)
+		time.Sleep(2 * time.Millisecond)
+
+		if err := cb.allow(); err != nil {
+			t.Fatalf("Expected trial request to be allowed, got %v", err)
+		}
+		if cb.current() != CircuitHalfOpen {
+			t.Errorf("Expected half-open, got %v", cb.current())
+		}
+		if err := cb.allow(); !errors.Is(err, ErrorCircuitOpen) {
+			t.Errorf("Expected only a single trial request, got %v", err)
+		}
+
+		cb.record(errServer)
+		if cb.current() != CircuitOpen {
+			t.Errorf("Expected open after failed trial, got %v", cb.current())
+		}
+
+		time.Sleep(2 * time.Millisecond)
+		cb.allow()
+		cb.record(nil)
+		if cb.current() != CircuitClosed {
+			t.Errorf("Expected closed after successful trial, got %v", cb.current())
+		}
+	})
+}
+
+// Test circuit breaker stops requests to the server
+func TestCircuitBreakerRequests(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	flags := &FeatureFlags{
+		client:   server.Client(),
+		httpAddr: server.URL,
+		project:  "test-project",
+		logger:   &testLogger{},
+		breaker:  newCircuitBreaker(2, time.Hour),
+	}
+	flags.state.Store(&State{
+		flagState:  make(map[string]FlagState),
+		valueState: make(map[string]ValueState),
+	})
+
+	for range 5 {
+		flags.Sync()
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests before the breaker opens, got %d", requests)
+	}
+	if flags.CircuitState() != CircuitOpen {
+		t.Errorf("Expected open circuit, got %v", flags.CircuitState())
+	}
+	if err := flags.Sync(); !errors.Is(err, ErrorCircuitOpen) {
+		t.Errorf("Expected ErrorCircuitOpen, got %v", err)
+	}
+}
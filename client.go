@@ -2,26 +2,47 @@ package featureflags
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand/v2"
 	"net/http"
+	"runtime/pprof"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	defaultSyncInterval   = 10 * time.Second
-	defaultRequestTimeout = 30 * time.Second
+	defaultSyncInterval    = 10 * time.Second
+	defaultRequestTimeout  = 30 * time.Second
+	defaultMaxResponseSize = 16 << 20
 )
 
+// State holds flags and values at a single version. Once published to the client it is
+// never modified: updates are applied to a copy, which then replaces it atomically.
 type State struct {
 	flagState  map[string]FlagState
 	flagNames  []string
 	valueState map[string]ValueState
 	valueNames []string
 	version    int
+	violations []ConstraintViolation
+	// sequence is the sequence number of the latest ChangeSet
+	sequence uint64
+	// aliases maps former names of flags to their current names
+	aliases map[string]string
+	// clock evaluates expiration and activation windows, the system clock if nil
+	clock Clock
+	// published is the time the state was published at, zero for the initial state.
+	// Change sets compare states as of their publication, see diffState.
+	published time.Time
 }
 
 func (state *State) Update(version int, flags []FlagResponse, values []ValueResponse) {
@@ -30,19 +51,53 @@ func (state *State) Update(version int, flags []FlagResponse, values []ValueResp
 	}
 
 	state.version = version
+	state.updateAliases(flags)
 	for _, flag := range flags {
+		// Preserve the default state if it exists
+		existingState := state.flagState[flag.Name]
 		state.flagState[flag.Name] = FlagState{
-			Name:    flag.Name,
-			Enabled: flag.Enabled,
+			Name:           flag.Name,
+			Enabled:        flag.Enabled,
+			DefaultEnabled: existingState.DefaultEnabled,
+			ExpiresAt:      timeOrZero(flag.ExpiresAt),
+			StartsAt:       timeOrZero(flag.StartsAt),
+			EndsAt:         timeOrZero(flag.EndsAt),
+			Payload:        flag.Payload,
+			DefaultPayload: existingState.DefaultPayload,
+			overridden:     existingState.overridden,
+			override:       existingState.override,
+			fromServer:     true,
 		}
 	}
 
+	state.violations = nil
 	for _, value := range values {
-		// Preserve the default value if it exists
+		// Preserve the default value and constraints if they exist
 		existingState, exists := state.valueState[value.Name]
 		defaultVal := interface{}(nil)
+		var constraints []Constraint
 		if exists {
 			defaultVal = existingState.DefaultValue
+			constraints = existingState.constraints
+		}
+
+		if err := checkConstraints(value.Value, constraints); err != nil {
+			// Reject the server value and keep using the default
+			state.violations = append(state.violations, ConstraintViolation{
+				Name:  value.Name,
+				Value: value.Value,
+				Err:   err,
+			})
+			state.valueState[value.Name] = ValueState{
+				Name:         value.Name,
+				Value:        defaultVal,
+				DefaultValue: defaultVal,
+				IsOverridden: false,
+				constraints:  constraints,
+				overridden:   existingState.overridden,
+				override:     existingState.override,
+			}
+			continue
 		}
 
 		state.valueState[value.Name] = ValueState{
@@ -50,6 +105,74 @@ func (state *State) Update(version int, flags []FlagResponse, values []ValueResp
 			Value:        value.Value,
 			DefaultValue: defaultVal,
 			IsOverridden: true, // Value came from server
+			ExpiresAt:    timeOrZero(value.ExpiresAt),
+			constraints:  constraints,
+			overridden:   existingState.overridden,
+			override:     existingState.override,
+		}
+	}
+
+	state.prune(flags, values)
+}
+
+// clone returns a copy of the state which can be updated without affecting readers
+// of the original. Payload slices are shared, as the state never modifies them in place.
+func (state *State) clone() *State {
+	next := &State{
+		flagState:  make(map[string]FlagState, len(state.flagState)),
+		flagNames:  state.flagNames,
+		valueState: make(map[string]ValueState, len(state.valueState)),
+		valueNames: state.valueNames,
+		version:    state.version,
+		violations: state.violations,
+		sequence:   state.sequence,
+		aliases:    state.aliases,
+		clock:      state.clock,
+	}
+	for name, flag := range state.flagState {
+		next.flagState[name] = flag
+	}
+	for name, value := range state.valueState {
+		next.valueState[name] = value
+	}
+	return next
+}
+
+// timeOrZero returns the time of an optional field, zero if it is nil
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// prune drops entries which are neither declared in defaults nor present in the
+// latest server response (e.g. flags renamed on the server), so the state
+// does not grow unbounded over the lifetime of the client. Local overrides are kept.
+func (state *State) prune(flags []FlagResponse, values []ValueResponse) {
+	keepFlags := make(map[string]struct{}, len(state.flagNames)+len(flags))
+	for _, name := range state.flagNames {
+		keepFlags[name] = struct{}{}
+	}
+	for _, flag := range flags {
+		keepFlags[flag.Name] = struct{}{}
+	}
+	for name, flag := range state.flagState {
+		if _, keep := keepFlags[name]; !keep && !flag.overridden {
+			delete(state.flagState, name)
+		}
+	}
+
+	keepValues := make(map[string]struct{}, len(state.valueNames)+len(values))
+	for _, name := range state.valueNames {
+		keepValues[name] = struct{}{}
+	}
+	for _, value := range values {
+		keepValues[value.Name] = struct{}{}
+	}
+	for name, value := range state.valueState {
+		if _, keep := keepValues[name]; !keep && !value.overridden {
+			delete(state.valueState, name)
 		}
 	}
 }
@@ -69,108 +192,343 @@ type FeatureFlags struct {
 	client       *http.Client
 	logger       Logger
 	project      string
-	state        State
+	state        atomic.Pointer[State]
 	variables    []Variable
 	httpAddr     string
 	syncInterval time.Duration
-	mu           sync.RWMutex
+	backoff      backoff
+	breaker      *circuitBreaker
+	headers      http.Header
+	intCoercion  IntCoercion
+	errorPolicy  ErrorPolicy
+	longPoll     time.Duration
+	// compress gzips request bodies, see WithRequestCompression
+	compress bool
+	// readOnly loads with sync requests, see WithReadOnly
+	readOnly bool
+	// maxResponse limits decoded response bodies, see WithMaxResponseSize
+	maxResponse int64
+	// slog receives structured events, see logEvent
+	slog     *slog.Logger
+	logLevel slog.Level
+	// events reports exposures to the server, nil unless WithExposureEvents is used
+	events *exposureReporter
+	// etag is the ETag of the last server response, sent with syncs of its version
+	etag syncETag
+	// usage counts evaluations, nil unless WithUsageStats is used
+	usage *usageTracker
+	// mu serializes state updates, reads load the state without locking
+	mu sync.Mutex
+
+	// sources are consulted in order by Load and Sync, the server if empty
+	sources []Source
+	// store persists the last known state between restarts
+	store    StateStore
+	hooks    []EvaluationHook
+	watchers watchers
+	// aliasUsage counts lookups by flag aliases, *atomic.Uint64 by alias
+	aliasUsage sync.Map
+	// coercions counts non-integral floats converted by int getters, *atomic.Uint64 by value
+	coercions sync.Map
+	// needsLoad is set when the initial Load failed in fail-open mode,
+	// the sync loop then retries Load instead of syncing
+	needsLoad atomic.Bool
+	// lastSync is the time of the last successful Load or Sync in unix nanoseconds
+	lastSync atomic.Int64
+	// rateLimitedUntil is the end of the last Retry-After hint in unix nanoseconds
+	rateLimitedUntil atomic.Int64
+	// trigger wakes up the sync loop, see TriggerSync
+	trigger chan struct{}
+	// transition stops the timer publishing the next expiration or activation window
+	// change of the state, see scheduleTransition. It is guarded by mu.
+	transition func() bool
+
+	// ctx is cancelled by Close or when the context passed to MakeClient is cancelled,
+	// but not by its deadline; it stops the sync loop and aborts in-flight requests
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// loadState returns the current state. It must not be modified.
+func (flags *FeatureFlags) loadState() *State {
+	if state := flags.state.Load(); state != nil {
+		return state
+	}
+	return &State{}
+}
+
+// context returns the client lifetime context, falling back to
+// context.Background for clients that were not created by MakeClient.
+func (flags *FeatureFlags) context() context.Context {
+	if flags.ctx == nil {
+		return context.Background()
+	}
+	return flags.ctx
 }
 
+// SyncLoop periodically syncs flags with the server until the client is closed.
+// After consecutive failures the interval grows exponentially with jitter, and
+// it is reset back to the sync interval after a successful sync. Syncs rejected
+// with ErrorUnauthorized are retried after the maximal delay right away, and no
+// sync is sent before the Retry-After hint of a 429 or 503 reply has passed.
+//
+// With long polling the next sync is sent right away, see WithLongPoll.
+// TriggerSync and TriggerCh run a sync before the interval has passed.
+//
+// The goroutine running the loop is tagged with pprof labels, see withLabels.
 func (flags *FeatureFlags) SyncLoop() {
+	delay := flags.syncInterval
+	if flags.longPoll > 0 {
+		delay = 0
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	ctx := pprof.WithLabels(flags.context(), flags.labels("sync"))
+	pprof.SetGoroutineLabels(ctx)
+	failures := 0
 	for {
-		time.Sleep(flags.syncInterval)
-		err := flags.Sync()
-		if err != nil {
-			flags.logger.Printf("Could not sync flags: %v", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-flags.trigger:
+		}
+		if wait := flags.rateLimitWait(); wait > 0 {
+			// Triggers don't override the Retry-After hint of the server
+			timer.Reset(wait)
+			continue
+		}
+
+		start := time.Now()
+		version := flags.loadState().version
+		if err := flags.syncOnce(withLongPoll(ctx)); err != nil {
+			failures++
+			delay := flags.backoff.delay(failures)
+			if errors.Is(err, ErrorUnauthorized) {
+				// Credentials don't fix themselves quickly, retry after the maximal delay
+				delay = flags.backoff.delay(math.MaxInt)
+			}
+			delay = max(delay, flags.rateLimitWait())
+			timer.Reset(delay)
 		} else {
-			flags.logger.Printf("Flags has been synced")
+			failures = 0
+			timer.Reset(flags.syncDelay(version, time.Since(start)))
+		}
+	}
+}
+
+// syncOnce runs a single iteration of the sync loop. It loads flags instead
+// of syncing them if the initial Load has failed in fail-open mode.
+func (flags *FeatureFlags) syncOnce(ctx context.Context) error {
+	start := time.Now()
+	version := flags.loadState().version
+	if flags.needsLoad.Load() {
+		if err := flags.LoadContext(ctx); err != nil {
+			flags.logEvent(slog.LevelWarn, "Could not load flags", err, flags.eventAttrs(start)...)
+			return err
 		}
+		flags.needsLoad.Store(false)
+		flags.logEvent(slog.LevelInfo, "Flags has been loaded", nil, flags.eventAttrs(start)...)
+		return nil
+	}
+
+	if err := flags.SyncContext(ctx); err != nil {
+		flags.logEvent(slog.LevelWarn, "Could not sync flags", err, flags.eventAttrs(start)...)
+		return err
 	}
+	// Syncs without changes are only logged at debug level, as they happen every interval
+	level := slog.LevelDebug
+	if flags.loadState().version != version {
+		level = slog.LevelInfo
+	}
+	flags.logEvent(level, "Flags has been synced", nil, flags.eventAttrs(start)...)
+	return nil
+}
+
+// eventAttrs returns attributes of a sync event which started at start
+func (flags *FeatureFlags) eventAttrs(start time.Time) []slog.Attr {
+	return []slog.Attr{
+		slog.String("project", flags.project),
+		slog.Int("version", flags.loadState().version),
+		slog.Duration("duration", time.Since(start)),
+	}
+}
+
+// Close stops the background sync loop and cancels in-flight requests.
+// It blocks until the sync loop has exited. Close is safe to call multiple times.
+func (flags *FeatureFlags) Close() error {
+	flags.closeOnce.Do(func() {
+		if flags.events != nil {
+			flags.events.close()
+		}
+		flags.shutdown()
+		if flags.done != nil {
+			<-flags.done
+		}
+	})
+	return nil
+}
+
+// shutdown cancels the client context and stops the transition timer
+func (flags *FeatureFlags) shutdown() {
+	if flags.cancel != nil {
+		flags.cancel()
+	}
+	flags.mu.Lock()
+	if flags.transition != nil {
+		flags.transition()
+	}
+	flags.mu.Unlock()
 }
 
 var ErrorCantSyncFlags = errors.New("can not sync flags")
 
+// Sync fetches changes of flags and values from the server.
 func (flags *FeatureFlags) Sync() error {
-	res, err := flags.SyncRequest()
+	return flags.SyncContext(flags.context())
+}
+
+// SyncContext is like Sync, but the request is also cancelled when ctx is done.
+func (flags *FeatureFlags) SyncContext(ctx context.Context) (err error) {
+	flags.withLabels(ctx, "sync", func(ctx context.Context) {
+		err = flags.syncContext(ctx)
+	})
+	return err
+}
+
+func (flags *FeatureFlags) syncContext(ctx context.Context) error {
+	res, fromServer, err := flags.fetch(ctx, false)
 	if err != nil {
 		return errors.Join(ErrorCantSyncFlags, err)
 	}
 
-	flags.mu.Lock()
-	defer flags.mu.Unlock()
-	flags.state.Update(res.Version, res.Flags, res.Values)
+	if flags.update(res.Version, res.Flags, res.Values) && fromServer {
+		flags.saveState(res)
+	}
+	flags.synced()
 	return nil
 }
 
-type SyncFlagsRequest struct {
-	Project string   `json:"project"`
-	Version int      `json:"version"`
-	Flags   []string `json:"flags"`
-	Values  []string `json:"values"`
+// update applies a server response to the state and reports whether the version has changed.
+func (flags *FeatureFlags) update(version int, flagResponses []FlagResponse, valueResponses []ValueResponse) bool {
+	flags.mu.Lock()
+	state := flags.loadState()
+	changed := state.version != version
+	var set ChangeSet
+	var violations []ConstraintViolation
+	if changed {
+		next := state.clone()
+		next.Update(version, flagResponses, valueResponses)
+		set = flags.publish(state, next)
+		violations = newViolations(state.violations, next.violations)
+	}
+	flags.mu.Unlock()
+
+	for _, violation := range violations {
+		flags.logEvent(slog.LevelWarn, "Value from server is rejected, using default", violation.Err,
+			slog.String("value", violation.Name))
+	}
+
+	if !set.empty() {
+		flags.logEvent(slog.LevelInfo, "Flags state has changed", nil, set.logAttrs()...)
+	}
+	// Notify outside of the lock, so listeners can read flags
+	flags.watchers.deliver()
+	return changed
 }
 
-type SyncFlagsResponse struct {
-	Version int             `json:"version"`
-	Flags   []FlagResponse  `json:"flags"`
-	Values  []ValueResponse `json:"values"`
+// publish replaces the state with next and records flag changes made by it.
+// It must be called with flags.mu held. The change set is queued for delivery, the caller
+// delivers it with watchers.deliver after releasing flags.mu.
+func (flags *FeatureFlags) publish(state, next *State) ChangeSet {
+	next.published = now(next.clock)
+	set := diffState(state, next)
+	if !set.empty() {
+		set.Time = next.published
+		next.sequence++
+		set.setSequence(next.sequence)
+		flags.watchers.record(set)
+		flags.watchers.enqueue(set)
+	}
+	flags.state.Store(next)
+	flags.scheduleTransition(next)
+	return set
 }
 
-func (flags *FeatureFlags) SyncRequest() (*SyncFlagsResponse, error) {
-	req := SyncFlagsRequest{
-		Project: flags.project,
-		Version: flags.state.version,
-		Flags:   flags.state.flagNames,
-		Values:  flags.state.valueNames,
+// saveState persists the state to the configured StateStore, if any.
+func (flags *FeatureFlags) saveState(state *LoadFlagsResponse) {
+	if flags.store == nil {
+		return
 	}
+	if err := flags.store.Save(flags.project, state); err != nil {
+		flags.logEvent(slog.LevelWarn, "Could not save flags state", err)
+	}
+}
 
-	body, err := json.Marshal(req)
+// restoreState bootstraps the state from the configured StateStore, if any.
+// It reports whether the state was restored.
+func (flags *FeatureFlags) restoreState() bool {
+	if flags.store == nil {
+		return false
+	}
+	state, err := flags.store.Load(flags.project)
 	if err != nil {
-		return nil, err
+		flags.logEvent(slog.LevelWarn, "Could not restore flags state", err)
+		return false
 	}
+	flags.update(state.Version, state.Flags, state.Values)
+	return true
+}
 
-	url := fmt.Sprintf("%s/flags/sync", flags.httpAddr)
-	res, err := flags.client.Post(
-		url, "application/json", bytes.NewBuffer(body),
-	)
-	if err != nil {
-		return nil, err
+func (flags *FeatureFlags) SyncRequest() (*SyncFlagsResponse, error) {
+	return flags.syncRequest(flags.context())
+}
+
+func (flags *FeatureFlags) syncRequest(ctx context.Context) (*SyncFlagsResponse, error) {
+	// The state is immutable, so the version and names come from a single snapshot
+	// without locking, even while a concurrent update publishes the next state
+	state := flags.loadState()
+	req := SyncFlagsRequest{
+		Project: flags.project,
+		Version: state.version,
+		Flags:   state.flagNames,
+		Values:  state.valueNames,
+		Wait:    flags.longPollWait(ctx),
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("http request to %s failed with status: %s", url, res.Status)
+	var header http.Header
+	if etag := flags.etag.get(state.version); etag != "" {
+		header = http.Header{"If-None-Match": {etag}}
 	}
 
 	var reply SyncFlagsResponse
-	err = json.NewDecoder(res.Body).Decode(&reply)
+	resHeader, err := flags.postHeader(ctx, "/flags/sync", req, header, &reply)
+	if errors.Is(err, errNotModified) {
+		// Nothing has changed since the version the ETag was received with
+		return &SyncFlagsResponse{Version: state.version}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-
+	flags.etag.set(reply.Version, resHeader.Get("ETag"))
 	return &reply, nil
 }
 
-type LoadFlagsRequest struct {
-	Project   string       `json:"project"`
-	Version   int          `json:"version"`
-	Variables []Variable   `json:"variables"`
-	Flags     []string     `json:"flags"`
-	Values    []ValueInput `json:"values"`
-}
-
-type LoadFlagsResponse struct {
-	Version int             `json:"version"`
-	Flags   []FlagResponse  `json:"flags"`
-	Values  []ValueResponse `json:"values"`
-}
-
 // LoadRequest sends a load request to the feature flags server.
 // This creates a project on the server if it doesn't exist, initializes flags, values, and variables,
 // and syncs the current project state from server to client.
 func (flags *FeatureFlags) LoadRequest() (*LoadFlagsResponse, error) {
-	// Build value inputs from current state
-	valueInputs := make([]ValueInput, 0, len(flags.state.valueState))
-	for _, valueState := range flags.state.valueState {
+	return flags.loadRequest(flags.context())
+}
+
+func (flags *FeatureFlags) loadRequest(ctx context.Context) (*LoadFlagsResponse, error) {
+	// Build value inputs from a single snapshot of the state, see syncRequest
+	state := flags.loadState()
+	valueInputs := make([]ValueInput, 0, len(state.valueState))
+	for _, valueState := range state.valueState {
 		valueInputs = append(valueInputs, ValueInput{
 			Name:  valueState.Name,
 			Value: valueState.Value,
@@ -179,37 +537,184 @@ func (flags *FeatureFlags) LoadRequest() (*LoadFlagsResponse, error) {
 
 	req := LoadFlagsRequest{
 		Project:   flags.project,
-		Version:   flags.state.version,
+		Version:   state.version,
 		Variables: flags.variables,
-		Flags:     flags.state.flagNames,
+		Flags:     state.flagNames,
 		Values:    valueInputs,
 	}
 
+	var reply LoadFlagsResponse
+	resHeader, err := flags.postHeader(ctx, "/flags/load", req, nil, &reply)
+	if err != nil {
+		return nil, err
+	}
+	flags.etag.set(reply.Version, resHeader.Get("ETag"))
+	return &reply, nil
+}
+
+// post sends req as JSON to the given server path and decodes the response into reply.
+// The request is cancelled when ctx is done or the client is closed.
+func (flags *FeatureFlags) post(ctx context.Context, path string, req any, reply any) error {
+	_, err := flags.postHeader(ctx, path, req, nil, reply)
+	return err
+}
+
+// postHeader is like post, but also sends the given header and returns the response header
+func (flags *FeatureFlags) postHeader(
+	ctx context.Context, path string, req any, header http.Header, reply any,
+) (http.Header, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(flags.context(), cancel)
+	defer stop()
+
+	httpReq, err := flags.newRequest(ctx, path, req)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		httpReq.Header[key] = values
+	}
+
+	if flags.breaker == nil {
+		resHeader, err := flags.do(httpReq, reply)
+		flags.rateLimited(err)
+		return resHeader, err
+	}
+	if err := flags.breaker.allow(); err != nil {
+		return nil, err
+	}
+	resHeader, err := flags.do(httpReq, reply)
+	flags.rateLimited(err)
+	if errors.Is(err, errNotModified) {
+		flags.breaker.record(nil)
+	} else {
+		flags.breaker.record(err)
+	}
+	return resHeader, err
+}
+
+// newRequest builds a POST request to the given server path with req as JSON body
+func (flags *FeatureFlags) newRequest(ctx context.Context, path string, req any) (*http.Request, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
+	if flags.compress {
+		if body, err = gzipBody(body); err != nil {
+			return nil, err
+		}
+	}
 
-	url := fmt.Sprintf("%s/flags/load", flags.httpAddr)
-	res, err := flags.client.Post(
-		url, "application/json", bytes.NewBuffer(body),
+	url := fmt.Sprintf("%s%s", flags.httpAddr, path)
+	httpReq, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, url, bytes.NewBuffer(body),
 	)
 	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
+	for key, values := range flags.headers {
+		httpReq.Header[key] = values
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if flags.compress {
+		httpReq.Header.Set("Content-Encoding", "gzip")
+	}
+	return httpReq, nil
+}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("http request to %s failed with status: %s", url, res.Status)
+func gzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	var reply LoadFlagsResponse
-	err = json.NewDecoder(res.Body).Decode(&reply)
+// ErrorResponseTooLarge is returned when a response exceeds WithMaxResponseSize
+var ErrorResponseTooLarge = errors.New("response is too large")
+
+// maxErrorBody limits the part of an error response body kept in HTTPError
+const maxErrorBody = 1 << 10
+
+var (
+	// ErrorUnauthorized matches HTTPError of 401 and 403 responses
+	ErrorUnauthorized = errors.New("unauthorized")
+	// ErrorProjectNotFound matches HTTPError of 404 responses
+	ErrorProjectNotFound = errors.New("project not found")
+)
+
+// HTTPError is returned when the server replies with an unexpected status. Use errors.Is
+// with ErrorUnauthorized or ErrorProjectNotFound to branch on common statuses, or
+// errors.As to inspect the status code and the beginning of the response body.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+	// RetryAfter is the Retry-After hint of 429 and 503 replies, zero if none was sent
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http request to %s failed with status: %s", e.URL, e.Status)
+}
+
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrorUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrorProjectNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// do sends the request and decodes the response into reply, unless reply is nil.
+// It returns errNotModified if the server replies 304 Not Modified.
+func (flags *FeatureFlags) do(httpReq *http.Request, reply any) (http.Header, error) {
+	res, err := flags.client.Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
 
-	return &reply, nil
+	if res.StatusCode == http.StatusNotModified {
+		return res.Header, errNotModified
+	}
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+		httpErr := &HTTPError{
+			URL:        httpReq.URL.String(),
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Body:       string(body),
+		}
+		if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable {
+			httpErr.RetryAfter = parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+		}
+		return nil, httpErr
+	}
+	if reply == nil {
+		return res.Header, nil
+	}
+
+	body := io.Reader(res.Body)
+	if flags.maxResponse > 0 {
+		body = http.MaxBytesReader(nil, res.Body, flags.maxResponse)
+	}
+	if err := json.NewDecoder(body).Decode(reply); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, fmt.Errorf("%w: response from %s exceeds %d bytes", ErrorResponseTooLarge, httpReq.URL, tooLarge.Limit)
+		}
+		return nil, err
+	}
+	return res.Header, nil
 }
 
 var ErrorCantLoadFlags = errors.New("can not load flags")
@@ -218,29 +723,62 @@ var ErrorCantLoadFlags = errors.New("can not load flags")
 // creating and initializing flags, values, and variables, and syncing the current
 // project state from the server to the client.
 func (flags *FeatureFlags) Load() error {
-	res, err := flags.LoadRequest()
+	return flags.LoadContext(flags.context())
+}
+
+// LoadContext is like Load, but the request is also cancelled when ctx is done.
+func (flags *FeatureFlags) LoadContext(ctx context.Context) (err error) {
+	flags.withLabels(ctx, "load", func(ctx context.Context) {
+		err = flags.loadContext(ctx)
+	})
+	return err
+}
+
+func (flags *FeatureFlags) loadContext(ctx context.Context) error {
+	res, fromServer, err := flags.fetch(ctx, true)
 	if err != nil {
+		// Fall back to the last known state, so the client can start
+		// while the server is unreachable
+		if flags.restoreState() {
+			flags.logEvent(slog.LevelWarn, "Could not load flags, using state from the state store", err)
+			return nil
+		}
 		return errors.Join(ErrorCantLoadFlags, err)
 	}
 
-	flags.mu.Lock()
-	defer flags.mu.Unlock()
-	flags.state.Update(res.Version, res.Flags, res.Values)
+	flags.update(res.Version, res.Flags, res.Values)
+	if fromServer {
+		flags.saveState(res)
+	}
+	flags.synced()
 	return nil
 }
 
-type VariableType int
+// loadRetry calls Load up to attempts times, waiting for delays of retry or the Retry-After
+// hint of the server in between, until ctx is done or its deadline would pass before the
+// next attempt
+func (flags *FeatureFlags) loadRetry(ctx context.Context, attempts int, retry backoff) error {
+	for attempt := 1; ; attempt++ {
+		err := flags.Load()
+		if err == nil || attempt >= attempts {
+			return err
+		}
 
-const (
-	TypeString    VariableType = iota + 1 // 1
-	TypeNumber                            // 2
-	TypeTimestamp                         // 3
-	TypeSet                               // 4
-)
+		delay := max(retry.delay(attempt), flags.rateLimitWait())
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+		flags.logEvent(slog.LevelWarn, "Could not load flags, retrying", err,
+			slog.Int("attempt", attempt), slog.Duration("delay", delay))
 
-type Variable struct {
-	Name string       `json:"name"`
-	Type VariableType `json:"type"`
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
 }
 
 type Defaults struct {
@@ -254,11 +792,117 @@ type ClientConfig struct {
 	syncInterval   time.Duration
 	requestTimeout time.Duration
 	logger         Logger
+	localPath      string
+	store          StateStore
+	failOpen       bool
+	hooks          []EvaluationHook
+	backoffMin     time.Duration
+	backoffMax     time.Duration
+	randSource     rand.Source
+	breaker        *circuitBreaker
+	headers        http.Header
+	intCoercion    IntCoercion
+	errorPolicy    ErrorPolicy
+	changeHistory  int
+	environment    string
+	longPoll       time.Duration
+	envPrefix      string
+	slog           *slog.Logger
+	logLevel       slog.Level
+	eventsInterval time.Duration
+	eventsMax      int
+	usageStats     bool
+	sources        []Source
+	sharedKV       KV
+	loadAttempts   int
+	loadBackoff    time.Duration
+	compress       bool
+	maxResponse    int64
+	clock          Clock
+	readOnly       bool
 }
 
 // ClientOption is a function that configures a ClientConfig
 type ClientOption func(*ClientConfig)
 
+var ErrorInvalidOptions = errors.New("invalid client options")
+
+// validate reports options which are out of range or have no effect in combination
+// with other options, so they are not silently ignored at runtime.
+func (c *ClientConfig) validate(httpAddr string) error {
+	var errs []error
+	if c.localPath != "" {
+		if len(c.headers) > 0 {
+			errs = append(errs, errors.New("request headers and authentication have no effect with WithLocalSource"))
+		}
+		if c.breaker != nil {
+			errs = append(errs, errors.New("WithCircuitBreaker has no effect with WithLocalSource"))
+		}
+		if c.compress || c.maxResponse != 0 {
+			errs = append(errs, errors.New("request compression and response size limits have no effect with WithLocalSource"))
+		}
+		if c.store != nil {
+			errs = append(errs, errors.New("WithStateStore has no effect with WithLocalSource"))
+		}
+		if c.eventsInterval != 0 {
+			errs = append(errs, errors.New("WithExposureEvents has no effect with WithLocalSource"))
+		}
+		if c.longPoll > 0 {
+			errs = append(errs, errors.New("WithLongPoll has no effect with WithLocalSource"))
+		}
+		if len(c.sources) > 0 {
+			errs = append(errs, errors.New("WithSources can't be combined with WithLocalSource"))
+		}
+		if c.sharedKV != nil {
+			errs = append(errs, errors.New("WithSharedCache can't be combined with WithLocalSource"))
+		}
+	} else if httpAddr == "" && (len(c.sources) == 0 || hasServer(c.sources)) {
+		errs = append(errs, errors.New("httpAddr is required unless WithLocalSource or WithSources without ServerSource is used"))
+	}
+	if c.sharedKV != nil && (len(c.sources) > 0 || c.store != nil) {
+		errs = append(errs, errors.New("WithSharedCache can't be combined with WithSources or WithStateStore"))
+	}
+	if c.slog != nil && c.logger != nil {
+		errs = append(errs, errors.New("WithLogger and WithSlog can't be used together"))
+	}
+	if c.backoffMin > 0 && c.backoffMax > 0 && c.backoffMax < c.backoffMin {
+		errs = append(errs, fmt.Errorf("backoff max %s is less than min %s", c.backoffMax, c.backoffMin))
+	}
+	if c.breaker != nil && c.breaker.coolDown <= 0 {
+		errs = append(errs, fmt.Errorf("circuit breaker cool-down must be positive, got %s", c.breaker.coolDown))
+	}
+	if c.intCoercion < 0 || c.intCoercion > IntCoercionStrict {
+		errs = append(errs, fmt.Errorf("unknown int coercion %d", c.intCoercion))
+	}
+	for _, action := range []ErrorAction{c.errorPolicy.UndefinedValue, c.errorPolicy.TypeMismatch, c.errorPolicy.UnknownFlag} {
+		if action < 0 || action > ErrorPanic {
+			errs = append(errs, fmt.Errorf("unknown error action %d", action))
+		}
+	}
+	if c.longPoll < 0 {
+		errs = append(errs, fmt.Errorf("long poll wait must not be negative, got %s", c.longPoll))
+	}
+	if c.changeHistory < 0 {
+		errs = append(errs, fmt.Errorf("change history size must not be negative, got %d", c.changeHistory))
+	}
+	if c.maxResponse < 0 {
+		errs = append(errs, fmt.Errorf("maximal response size must not be negative, got %d", c.maxResponse))
+	}
+	if c.loadAttempts < 0 || c.loadBackoff < 0 || (c.loadBackoff > 0 && c.loadAttempts == 0) {
+		errs = append(errs, fmt.Errorf("load retry needs positive attempts and a non-negative backoff, got %d and %s",
+			c.loadAttempts, c.loadBackoff))
+	}
+	if c.eventsInterval < 0 || (c.eventsInterval > 0 && c.eventsMax <= 0) {
+		errs = append(errs, fmt.Errorf("exposure events need a positive interval and buffer size, got %s and %d",
+			c.eventsInterval, c.eventsMax))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.Join(append([]error{ErrorInvalidOptions}, errs...)...)
+}
+
 // WithVariables sets the variables for targeting rules
 func WithVariables(variables []Variable) ClientOption {
 	return func(c *ClientConfig) {
@@ -273,6 +917,129 @@ func WithSyncInterval(interval time.Duration) ClientOption {
 	}
 }
 
+// WithBackoff sets the delay bounds between sync attempts after failures.
+// The delay starts at min and doubles on every consecutive failure up to max,
+// with random jitter; it is reset to the sync interval after a successful sync.
+//
+// Default values: min is the sync interval, max is 5 minutes (defaultBackoffMax).
+func WithBackoff(min, max time.Duration) ClientOption {
+	return func(c *ClientConfig) {
+		c.backoffMin = min
+		c.backoffMax = max
+	}
+}
+
+// WithRandSource sets the source of randomness used for backoff jitter,
+// so that retry timings can be reproduced exactly, e.g. in simulations.
+//
+// The source is used by a single goroutine, so it does not have to be safe for concurrent use.
+func WithRandSource(source rand.Source) ClientOption {
+	return func(c *ClientConfig) {
+		c.randSource = source
+	}
+}
+
+// WithCircuitBreaker stops sending requests to the server for coolDown after threshold
+// consecutive failures. After the cool-down a single trial request is sent: on success
+// the breaker is closed again, on failure it stays open for another cool-down.
+// Requests rejected by the breaker fail with ErrorCircuitOpen.
+//
+// The circuit breaker is disabled by default. Its state is reported by CircuitState.
+func WithCircuitBreaker(threshold int, coolDown time.Duration) ClientOption {
+	return func(c *ClientConfig) {
+		c.breaker = newCircuitBreaker(max(threshold, 1), coolDown)
+	}
+}
+
+// WithRequestHeader sets a header sent with every request to the feature flags server,
+// e.g. credentials required by a gateway in front of the server.
+func WithRequestHeader(key, value string) ClientOption {
+	return func(c *ClientConfig) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Set(key, value)
+	}
+}
+
+// WithAuthToken authenticates requests to the feature flags server with a bearer token.
+func WithAuthToken(token string) ClientOption {
+	return WithRequestHeader("Authorization", "Bearer "+token)
+}
+
+// WithBasicAuth authenticates requests to the feature flags server with HTTP basic authentication.
+func WithBasicAuth(username, password string) ClientOption {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return WithRequestHeader("Authorization", "Basic "+credentials)
+}
+
+// WithIntCoercion sets how int getters convert non-integral float values,
+// e.g. 3.99 set on the server for an int value.
+//
+// Default value: IntCoercionTruncate. Truncated and rounded reads are counted by
+// IntCoercions, and a warning is logged on the first one of every value; with
+// IntCoercionStrict such values are treated as a type mismatch.
+func WithIntCoercion(coercion IntCoercion) ClientOption {
+	return func(c *ClientConfig) {
+		c.intCoercion = coercion
+	}
+}
+
+// WithChangeHistory keeps the latest size change sets, so consumers processing
+// changes asynchronously can catch up using ChangesSince.
+func WithChangeHistory(size int) ClientOption {
+	return func(c *ClientConfig) {
+		c.changeHistory = size
+	}
+}
+
+// WithLongPoll makes the sync loop ask the server to hold sync requests for up to wait
+// until the version changes, so changes are received in near real time without streaming.
+// After a held request or a change the next one is sent right away. If the server replies
+// quickly without changes, e.g. because it doesn't support long polling, the loop falls back
+// to the sync interval. Sync and SyncContext called directly are never held.
+//
+// The HTTP client timeout is extended by wait.
+func WithLongPoll(wait time.Duration) ClientOption {
+	return func(c *ClientConfig) {
+		c.longPoll = wait
+	}
+}
+
+// WithEnvOverrides overrides declared flags and values from environment variables when the
+// client is created, e.g. FF_OVERRIDE_NEW_CHECKOUT=true with the prefix FF_OVERRIDE_.
+// Variable names are the prefix followed by the upper-cased name, where characters other than
+// letters and digits are replaced by "_". Values are parsed according to the type of their default.
+//
+// Overrides take precedence over the server state, see Override. MakeClient fails
+// with ErrorInvalidOptions if a variable can't be parsed.
+func WithEnvOverrides(prefix string) ClientOption {
+	return func(c *ClientConfig) {
+		c.envPrefix = prefix
+	}
+}
+
+// WithEnvironment targets the flags environment by namespacing the project:
+// requests and the state store use "<project>.<environment>" as the project name.
+// This lets one codebase target e.g. staging and production flags without building
+// project names in every service.
+func WithEnvironment(environment string) ClientOption {
+	return func(c *ClientConfig) {
+		c.environment = environment
+	}
+}
+
+// WithErrorPolicy sets how the client reacts to misuses of flags and values: undefined
+// values, type mismatches and unknown flags. See StrictErrorPolicy, LenientErrorPolicy
+// and ProductionErrorPolicy for presets.
+//
+// By default undefined values panic, type mismatches are logged and unknown flags are ignored.
+func WithErrorPolicy(policy ErrorPolicy) ClientOption {
+	return func(c *ClientConfig) {
+		c.errorPolicy = policy
+	}
+}
+
 // WithLogger sets the logger for the client
 func WithLogger(logger Logger) ClientOption {
 	return func(c *ClientConfig) {
@@ -280,6 +1047,43 @@ func WithLogger(logger Logger) ClientOption {
 	}
 }
 
+// WithSlog logs with a structured logger instead of the Logger set by WithLogger.
+// Sync events are logged with fields like project, version, duration and error,
+// other messages are logged as warnings.
+func WithSlog(logger *slog.Logger) ClientOption {
+	return func(c *ClientConfig) {
+		c.slog = logger
+	}
+}
+
+// WithLogLevel sets the minimal level of logged client events. By default it is
+// slog.LevelInfo: flag changes and errors are logged, while syncs which haven't
+// changed the version are logged at slog.LevelDebug.
+func WithLogLevel(level slog.Level) ClientOption {
+	return func(c *ClientConfig) {
+		c.logLevel = level
+	}
+}
+
+// WithExposureEvents reports flag and value evaluations to the server's /flags/events
+// endpoint for experiment analysis. Events are buffered and posted every interval,
+// or as soon as maxEvents are buffered; events beyond maxEvents are dropped and only
+// counted until the next batch. Remaining events are sent on Close.
+func WithExposureEvents(interval time.Duration, maxEvents int) ClientOption {
+	return func(c *ClientConfig) {
+		c.eventsInterval = interval
+		c.eventsMax = maxEvents
+	}
+}
+
+// WithUsageStats counts evaluations of every flag and value and records the time
+// of the last one, see Stats. It helps to find flags which are defined but never read.
+func WithUsageStats() ClientOption {
+	return func(c *ClientConfig) {
+		c.usageStats = true
+	}
+}
+
 // WithRequestTimeout sets the timeout for HTTP requests to the feature flags server.
 // This timeout applies to all HTTP operations including Load and Sync requests.
 //
@@ -305,6 +1109,122 @@ func WithRequestTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
+// WithSources sets the chain of sources Load and Sync take the state from: the first source
+// which succeeds is used. Use ServerSource to place the feature flags server in the chain,
+// e.g. WithSources(ServerSource(), StoreSource(store), FileSource(path)). httpAddr is only
+// required if the chain includes the server.
+func WithSources(sources ...Source) ClientOption {
+	return func(c *ClientConfig) {
+		c.sources = sources
+	}
+}
+
+// WithLocalSource reads flags and values from a JSON file instead of the feature flags server.
+// The file is polled: its modification time is checked on every sync interval and the file
+// is re-read when it changes, so it can be edited while the application is running. YAML
+// is not supported. httpAddr is ignored in this mode.
+//
+// This is intended for development environments and air-gapped deployments.
+// See localSource for the file format.
+func WithLocalSource(path string) ClientOption {
+	return func(c *ClientConfig) {
+		c.localPath = path
+	}
+}
+
+// WithStateStore persists the last successfully loaded state in store. When the initial
+// Load fails, the client bootstraps from the stored state instead of failing.
+func WithStateStore(store StateStore) ClientOption {
+	return func(c *ClientConfig) {
+		c.store = store
+	}
+}
+
+// WithSharedCache shares the state between instances through kv, e.g. Redis, so a fleet
+// keeps a single connection to the feature flags server. One instance holds a lease in kv,
+// syncs with the server and saves every new state to kv; the other instances read the
+// state from kv and only fall back to the server if kv fails. If kv implements KVNotifier,
+// instances sync as soon as the shared state is updated.
+func WithSharedCache(kv KV) ClientOption {
+	return func(c *ClientConfig) {
+		c.sharedKV = kv
+	}
+}
+
+// WithStateCache is a shortcut for WithStateStore(NewFileStateStore(dir)).
+func WithStateCache(dir string) ClientOption {
+	return WithStateStore(NewFileStateStore(dir))
+}
+
+// WithFailOpen makes MakeClient return a working client that uses defaults when the
+// initial Load fails, instead of returning an error. Load is then retried on every
+// sync interval until it succeeds.
+func WithFailOpen() ClientOption {
+	return func(c *ClientConfig) {
+		c.failOpen = true
+	}
+}
+
+// WithReadOnly makes Load send a sync request for the declared names instead of a load
+// request, so the client never creates projects, flags or values on the server, nor
+// sends default values. Flags and values unknown to the server keep their defaults.
+// It is meant for tools inspecting a project, see cmd/ffctl.
+func WithReadOnly() ClientOption {
+	return func(c *ClientConfig) {
+		c.readOnly = true
+	}
+}
+
+// WithRequestCompression gzips bodies of requests to the server, which reduces bandwidth
+// of load and sync requests declaring many flags. The server must accept gzip content
+// encoding. Gzipped responses are decompressed regardless of this option.
+func WithRequestCompression() ClientOption {
+	return func(c *ClientConfig) {
+		c.compress = true
+	}
+}
+
+// WithMaxResponseSize limits the size of decompressed response bodies, so a huge state
+// fails the request with ErrorResponseTooLarge instead of exhausting memory.
+//
+// Default value: 16 MiB (defaultMaxResponseSize).
+func WithMaxResponseSize(bytes int64) ClientOption {
+	return func(c *ClientConfig) {
+		c.maxResponse = bytes
+	}
+}
+
+// WithLoadRetry makes MakeClient try the initial Load up to attempts times, so transient
+// DNS failures or a rolling deploy of the server don't fail the application startup.
+// The delay between attempts starts at backoff and doubles on every failure, with jitter,
+// unless the server asks to wait longer with Retry-After.
+// Retrying stops early when the context passed to MakeClient is done, or when its
+// deadline would pass before the next attempt, so a deadline on that context bounds
+// the startup. It doesn't stop the client afterwards. Load is idempotent on the server, so
+// an attempt which failed after the server has handled it is safe to repeat.
+//
+// With WithStateStore, a failed Load uses the saved state right away and is not retried.
+func WithLoadRetry(attempts int, backoff time.Duration) ClientOption {
+	return func(c *ClientConfig) {
+		c.loadAttempts = attempts
+		c.loadBackoff = backoff
+	}
+}
+
+// WithEvaluationHook registers a hook invoked after every flag and value evaluation,
+// e.g. to log experiment exposures or trace lookups. It can be used multiple times.
+func WithEvaluationHook(hook EvaluationHook) ClientOption {
+	return func(c *ClientConfig) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+// MakeClient creates a FeatureFlags client, loads the initial state from the server
+// and starts a background sync loop. The deadline of ctx bounds the initial load only:
+// the sync loop runs until ctx is cancelled or Close is called. Once the deadline has
+// passed, later cancellation of ctx is not noticed, so only Close stops the client.
+//
+// Invalid combinations of options are rejected with ErrorInvalidOptions.
 func MakeClient(
 	ctx context.Context,
 	httpAddr string,
@@ -324,8 +1244,17 @@ func MakeClient(
 	for _, opt := range opts {
 		opt(config)
 	}
+	if err := config.validate(httpAddr); err != nil {
+		return nil, err
+	}
+	if config.environment != "" {
+		project = project + "." + config.environment
+	}
 
 	// Use default logger if none provided
+	if config.slog != nil {
+		config.logger = &slogLogger{logger: config.slog}
+	}
 	if config.logger == nil {
 		config.logger = &defaultLogger{}
 	}
@@ -335,20 +1264,28 @@ func MakeClient(
 		config.requestTimeout = defaultRequestTimeout
 	}
 
-	client := &http.Client{
-		Timeout: config.requestTimeout,
+	if config.maxResponse == 0 {
+		config.maxResponse = defaultMaxResponseSize
 	}
+
+	client, httpAddr := newHTTPClient(httpAddr, config.requestTimeout+config.longPoll)
 	flagsMap := make(map[string]FlagState, len(defaults.Flags))
 	flagNames := make([]string, len(defaults.Flags))
+	aliases := make(map[string]string)
 	valuesMap := make(map[string]ValueState, len(defaults.Values))
 	valueNames := make([]string, len(defaults.Values))
 
 	for i, flag := range defaults.Flags {
 		flagsMap[flag.Name] = FlagState{
-			Name:    flag.Name,
-			Enabled: flag.Enabled,
+			Name:           flag.Name,
+			Enabled:        flag.Enabled,
+			DefaultEnabled: flag.Enabled,
+			DefaultPayload: flag.Payload,
 		}
 		flagNames[i] = flag.Name
+		for _, alias := range flag.Aliases {
+			aliases[alias] = flag.Name
+		}
 	}
 
 	for i, value := range defaults.Values {
@@ -357,6 +1294,7 @@ func MakeClient(
 			Value:        value.Value,
 			DefaultValue: value.Value,
 			IsOverridden: false,
+			constraints:  value.Constraints,
 		}
 		valueNames[i] = value.Name
 	}
@@ -365,27 +1303,116 @@ func MakeClient(
 		config.syncInterval = defaultSyncInterval
 	}
 
+	if config.backoffMin <= 0 {
+		config.backoffMin = config.syncInterval
+	}
+	if config.backoffMax <= 0 {
+		config.backoffMax = defaultBackoffMax
+	}
+	if config.backoffMax < config.backoffMin {
+		config.backoffMax = config.backoffMin
+	}
+
+	// The client outlives the deadline of ctx, which is meant for the startup
+	clientCtx, cancelClient := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			cancelClient()
+		}
+	})
+	cancel := func() {
+		stop()
+		cancelClient()
+	}
+	var random *rand.Rand
+	if config.randSource != nil {
+		random = rand.New(config.randSource)
+	}
+
 	flagsClient := FeatureFlags{
-		client:    client,
-		project:   project,
-		httpAddr:  httpAddr,
-		variables: config.variables,
-		state: State{
-			flagState:  flagsMap,
-			flagNames:  flagNames,
-			valueState: valuesMap,
-			valueNames: valueNames,
-		},
+		client:       client,
+		project:      project,
+		httpAddr:     httpAddr,
+		variables:    config.variables,
 		logger:       config.logger,
 		syncInterval: config.syncInterval,
+		backoff:      backoff{min: config.backoffMin, max: config.backoffMax, rand: random},
+		breaker:      config.breaker,
+		headers:      config.headers,
+		compress:     config.compress,
+		readOnly:     config.readOnly,
+		maxResponse:  config.maxResponse,
+		intCoercion:  config.intCoercion,
+		errorPolicy:  config.errorPolicy,
+		longPoll:     config.longPoll,
+		slog:         config.slog,
+		logLevel:     config.logLevel,
+		ctx:          clientCtx,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+		trigger:      make(chan struct{}, 1),
+		store:        config.store,
+		hooks:        config.hooks,
+	}
+	flagsClient.watchers.historySize = config.changeHistory
+	flagsClient.state.Store(&State{
+		flagState:  flagsMap,
+		flagNames:  flagNames,
+		valueState: valuesMap,
+		valueNames: valueNames,
+		aliases:    aliases,
+		clock:      config.clock,
+	})
+	switch {
+	case config.localPath != "":
+		flagsClient.sources = []Source{FileSource(config.localPath)}
+	case config.sharedKV != nil:
+		// The lease outlives a few sync intervals, so a single failed sync of
+		// the leader doesn't make the other instances compete for it. Saves and loads
+		// of the shared state are bounded by the request timeout like server requests.
+		cache := newKVCache(clientCtx, config.sharedKV, 3*config.syncInterval, config.requestTimeout)
+		flagsClient.sources = []Source{cache, ServerSource()}
+		flagsClient.store = cache
+		if err := flagsClient.subscribe(cache); err != nil {
+			flagsClient.shutdown()
+			return nil, err
+		}
+	default:
+		flagsClient.sources = config.sources
+	}
+	flagsClient.watchSources()
+	if config.envPrefix != "" {
+		if err := flagsClient.applyEnvOverrides(config.envPrefix); err != nil {
+			flagsClient.shutdown()
+			return nil, errors.Join(ErrorInvalidOptions, err)
+		}
 	}
 	// Load will create a project on the server if it doesn't exist,
 	// create and initialize flags, values and variables, and will sync
 	// current project state from server to client
-	err := flagsClient.Load()
+	err := flagsClient.loadRetry(ctx, config.loadAttempts,
+		backoff{min: config.loadBackoff, max: defaultBackoffMax, rand: random})
 	if err != nil {
-		return nil, err
+		if !config.failOpen {
+			flagsClient.shutdown()
+			return nil, err
+		}
+		flagsClient.logEvent(slog.LevelWarn, "Could not load flags, using defaults", err)
+		flagsClient.needsLoad.Store(true)
+	}
+	if config.usageStats {
+		flagsClient.usage = &usageTracker{}
+		flagsClient.hooks = append(flagsClient.hooks, flagsClient.usage)
+	}
+	if config.eventsInterval > 0 {
+		flagsClient.events = newExposureReporter(&flagsClient, config.eventsInterval, config.eventsMax)
+		flagsClient.hooks = append(flagsClient.hooks, flagsClient.events)
+		go flagsClient.events.run()
 	}
-	go flagsClient.SyncLoop()
+	// The sync loop runs until Close is called or ctx is cancelled
+	go func() {
+		defer close(flagsClient.done)
+		flagsClient.SyncLoop()
+	}()
 	return &flagsClient, nil
 }
@@ -1,10 +1,16 @@
 package featureflags
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -70,14 +76,14 @@ func TestLoadRequest(t *testing.T) {
 			httpAddr: server.URL,
 			project:  "test-project",
 			logger:   &testLogger{},
-			state: State{
-				version:    0,
-				flagState:  make(map[string]FlagState),
-				flagNames:  []string{"test_flag"},
-				valueState: make(map[string]ValueState),
-				valueNames: []string{"test_value"},
-			},
 		}
+		flags.state.Store(&State{
+			version:    0,
+			flagState:  make(map[string]FlagState),
+			flagNames:  []string{"test_flag"},
+			valueState: make(map[string]ValueState),
+			valueNames: []string{"test_value"},
+		})
 
 		// Call LoadRequest
 		resp, err := flags.LoadRequest()
@@ -111,11 +117,11 @@ func TestLoadRequest(t *testing.T) {
 			httpAddr: server.URL,
 			project:  "test-project",
 			logger:   &testLogger{},
-			state: State{
-				flagState:  make(map[string]FlagState),
-				valueState: make(map[string]ValueState),
-			},
 		}
+		flags.state.Store(&State{
+			flagState:  make(map[string]FlagState),
+			valueState: make(map[string]ValueState),
+		})
 
 		_, err := flags.LoadRequest()
 		if err == nil {
@@ -147,14 +153,14 @@ func TestLoad(t *testing.T) {
 		httpAddr: server.URL,
 		project:  "test-project",
 		logger:   &testLogger{},
-		state: State{
-			version:    0,
-			flagState:  make(map[string]FlagState),
-			flagNames:  []string{"feature_a", "feature_b"},
-			valueState: make(map[string]ValueState),
-			valueNames: []string{"timeout"},
-		},
 	}
+	flags.state.Store(&State{
+		version:    0,
+		flagState:  make(map[string]FlagState),
+		flagNames:  []string{"feature_a", "feature_b"},
+		valueState: make(map[string]ValueState),
+		valueNames: []string{"timeout"},
+	})
 
 	err := flags.Load()
 	if err != nil {
@@ -162,13 +168,13 @@ func TestLoad(t *testing.T) {
 	}
 
 	// Verify state was updated
-	if flags.state.version != 2 {
-		t.Errorf("Expected version 2, got %d", flags.state.version)
+	if flags.loadState().version != 2 {
+		t.Errorf("Expected version 2, got %d", flags.loadState().version)
 	}
-	if !flags.state.FlagState("feature_a") {
+	if !flags.loadState().FlagState("feature_a") {
 		t.Error("Expected feature_a to be enabled")
 	}
-	if flags.state.FlagState("feature_b") {
+	if flags.loadState().FlagState("feature_b") {
 		t.Error("Expected feature_b to be disabled")
 	}
 }
@@ -200,14 +206,14 @@ func TestSyncRequest(t *testing.T) {
 			httpAddr: server.URL,
 			project:  "test-project",
 			logger:   &testLogger{},
-			state: State{
-				version:    2,
-				flagState:  make(map[string]FlagState),
-				flagNames:  []string{"sync_flag"},
-				valueState: make(map[string]ValueState),
-				valueNames: []string{"sync_value"},
-			},
 		}
+		flags.state.Store(&State{
+			version:    2,
+			flagState:  make(map[string]FlagState),
+			flagNames:  []string{"sync_flag"},
+			valueState: make(map[string]ValueState),
+			valueNames: []string{"sync_value"},
+		})
 
 		resp, err := flags.SyncRequest()
 		if err != nil {
@@ -242,22 +248,22 @@ func TestSync(t *testing.T) {
 		httpAddr: server.URL,
 		project:  "test-project",
 		logger:   &testLogger{},
-		state: State{
-			version:    4,
-			flagState:  make(map[string]FlagState),
-			flagNames:  []string{"updated_flag"},
-			valueState: make(map[string]ValueState),
-			valueNames: []string{"updated_value"},
-		},
 	}
+	flags.state.Store(&State{
+		version:    4,
+		flagState:  make(map[string]FlagState),
+		flagNames:  []string{"updated_flag"},
+		valueState: make(map[string]ValueState),
+		valueNames: []string{"updated_value"},
+	})
 
 	err := flags.Sync()
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
 
-	if flags.state.version != 5 {
-		t.Errorf("Expected version 5, got %d", flags.state.version)
+	if flags.loadState().version != 5 {
+		t.Errorf("Expected version 5, got %d", flags.loadState().version)
 	}
 }
 
@@ -306,6 +312,7 @@ func TestMakeClient(t *testing.T) {
 	if client == nil {
 		t.Fatal("Expected client to be non-nil")
 	}
+	defer client.Close()
 
 	// Verify defaults were set correctly
 	if !client.Get("init_flag") {
@@ -359,3 +366,879 @@ func TestStateUpdate(t *testing.T) {
 		t.Error("Expected IsOverridden to be true")
 	}
 }
+
+// Test Close stops the sync loop
+func TestClose(t *testing.T) {
+	newServer := func() *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(LoadFlagsResponse{Version: 1})
+		}))
+	}
+
+	t.Run("close stops sync loop", func(t *testing.T) {
+		server := newServer()
+		defer server.Close()
+
+		client, err := MakeClient(
+			context.Background(),
+			server.URL,
+			"test-project",
+			Defaults{},
+			WithSyncInterval(time.Millisecond),
+		)
+		if err != nil {
+			t.Fatalf("MakeClient failed: %v", err)
+		}
+
+		if err := client.Close(); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+		select {
+		case <-client.done:
+		default:
+			t.Error("Expected sync loop to be stopped after Close")
+		}
+
+		// Close is idempotent
+		if err := client.Close(); err != nil {
+			t.Errorf("Second Close failed: %v", err)
+		}
+	})
+
+	t.Run("cancelled context stops sync loop", func(t *testing.T) {
+		server := newServer()
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		client, err := MakeClient(
+			ctx,
+			server.URL,
+			"test-project",
+			Defaults{},
+			WithSyncInterval(time.Millisecond),
+		)
+		if err != nil {
+			t.Fatalf("MakeClient failed: %v", err)
+		}
+
+		cancel()
+		select {
+		case <-client.done:
+		case <-time.After(time.Second):
+			t.Error("Expected sync loop to stop after context cancellation")
+		}
+	})
+
+	t.Run("deadline bounds only the startup", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			json.NewEncoder(w).Encode(LoadFlagsResponse{Version: 1})
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		client, err := MakeClient(
+			ctx,
+			server.URL,
+			"test-project",
+			Defaults{},
+			WithSyncInterval(10*time.Millisecond),
+			WithLogger(&testLogger{}),
+		)
+		if err != nil {
+			t.Fatalf("MakeClient failed: %v", err)
+		}
+		defer client.Close()
+
+		<-ctx.Done()
+		synced := requests.Load()
+		time.Sleep(100 * time.Millisecond)
+		if requests.Load() <= synced {
+			t.Error("Expected the sync loop to keep syncing after the startup deadline")
+		}
+		select {
+		case <-client.done:
+			t.Error("Expected the sync loop to run after the startup deadline")
+		default:
+		}
+	})
+}
+
+// Test WithFailOpen starts with defaults and retries Load in background
+func TestFailOpen(t *testing.T) {
+	var available atomic.Bool
+	var loads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/flags/load" {
+			loads.Add(1)
+		}
+		resp := LoadFlagsResponse{
+			Version: 1,
+			Flags:   []FlagResponse{{Name: "fail_open_flag", Enabled: true}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	defaults := Defaults{
+		Flags: []Flag{{Name: "fail_open_flag", Enabled: false}},
+	}
+
+	t.Run("error without fail open", func(t *testing.T) {
+		_, err := MakeClient(context.Background(), server.URL, "test-project", defaults)
+		if err == nil {
+			t.Error("Expected error when server is unavailable")
+		}
+	})
+
+	t.Run("defaults with fail open", func(t *testing.T) {
+		client, err := MakeClient(
+			context.Background(),
+			server.URL,
+			"test-project",
+			defaults,
+			WithFailOpen(),
+			WithSyncInterval(5*time.Millisecond),
+		)
+		if err != nil {
+			t.Fatalf("Expected MakeClient to succeed with fail open, got: %v", err)
+		}
+		defer client.Close()
+
+		if client.Get("fail_open_flag") {
+			t.Error("Expected fail_open_flag to use default value")
+		}
+
+		available.Store(true)
+		deadline := time.Now().Add(time.Second)
+		for !client.Get("fail_open_flag") {
+			if time.Now().After(deadline) {
+				t.Fatal("Expected fail_open_flag to be loaded in background")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if loads.Load() != 1 {
+			t.Errorf("Expected exactly one successful load, got %d", loads.Load())
+		}
+	})
+}
+
+// Test WithReadOnly loads with a sync request, which doesn't register names on the server
+func TestReadOnly(t *testing.T) {
+	var paths []string
+	var received SyncFlagsRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&received)
+		json.NewEncoder(w).Encode(SyncFlagsResponse{
+			Version: 3,
+			Flags:   []FlagResponse{{Name: "known_flag", Enabled: true}},
+		})
+	}))
+	defer server.Close()
+
+	defaults := Defaults{Flags: []Flag{{Name: "known_flag"}, {Name: "typo_flag"}}}
+	client, err := MakeClient(context.Background(), server.URL, "test-project", defaults,
+		WithReadOnly(), WithSyncInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if len(paths) != 1 || paths[0] != "/flags/sync" {
+		t.Errorf("Expected a single sync request, got %v", paths)
+	}
+	if received.Version != 0 || len(received.Flags) != 2 {
+		t.Errorf("Expected a sync of all declared flags as of version 0, got %+v", received)
+	}
+	if !client.Get("known_flag") || client.Get("typo_flag") || client.Version() != 3 {
+		t.Error("Expected the state of the server with defaults for unknown flags")
+	}
+}
+
+// Test WithLoadRetry retries the initial Load until it succeeds or runs out of attempts
+func TestLoadRetry(t *testing.T) {
+	var failures, loads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loads.Add(1)
+		if failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(LoadFlagsResponse{
+			Version: 1,
+			Flags:   []FlagResponse{{Name: "test_flag", Enabled: true}},
+		})
+	}))
+	defer server.Close()
+
+	defaults := Defaults{Flags: []Flag{{Name: "test_flag"}}}
+	tests := []struct {
+		name      string
+		ctx       func() (context.Context, context.CancelFunc)
+		failures  int32
+		attempts  int
+		backoff   time.Duration
+		wantLoads int32
+		wantErr   bool
+	}{
+		{
+			name:      "succeeds after failures",
+			failures:  2,
+			attempts:  3,
+			backoff:   time.Millisecond,
+			wantLoads: 3,
+		},
+		{
+			name:      "runs out of attempts",
+			failures:  5,
+			attempts:  3,
+			backoff:   time.Millisecond,
+			wantLoads: 3,
+			wantErr:   true,
+		},
+		{
+			name: "stops before the deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Second)
+			},
+			failures:  5,
+			attempts:  3,
+			backoff:   time.Minute,
+			wantLoads: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if tt.ctx != nil {
+				ctx, cancel = tt.ctx()
+			}
+			defer cancel()
+			failures.Store(tt.failures)
+			loads.Store(0)
+
+			flags, err := MakeClient(ctx, server.URL, "test-project", defaults,
+				WithLoadRetry(tt.attempts, tt.backoff), WithLogger(&testLogger{}))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil {
+				defer flags.Close()
+				if !flags.Get("test_flag") {
+					t.Error("Expected test_flag to be loaded")
+				}
+			}
+			if loads.Load() != tt.wantLoads {
+				t.Errorf("Expected %d loads, got %d", tt.wantLoads, loads.Load())
+			}
+		})
+	}
+}
+
+// Test unexpected statuses are returned as HTTPError matching the error kinds
+func TestHTTPError(t *testing.T) {
+	tests := []struct {
+		status       int
+		unauthorized bool
+		notFound     bool
+	}{
+		{http.StatusUnauthorized, true, false},
+		{http.StatusForbidden, true, false},
+		{http.StatusNotFound, false, true},
+		{http.StatusServiceUnavailable, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "details", tt.status)
+			}))
+			defer server.Close()
+
+			flags := &FeatureFlags{client: server.Client(), httpAddr: server.URL, project: "test-project"}
+			_, err := flags.LoadRequest()
+
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("Expected HTTPError, got %v", err)
+			}
+			if httpErr.StatusCode != tt.status || httpErr.Body != "details\n" {
+				t.Errorf("Unexpected HTTPError %+v", httpErr)
+			}
+			if errors.Is(err, ErrorUnauthorized) != tt.unauthorized {
+				t.Errorf("Expected errors.Is ErrorUnauthorized to be %v", tt.unauthorized)
+			}
+			if errors.Is(err, ErrorProjectNotFound) != tt.notFound {
+				t.Errorf("Expected errors.Is ErrorProjectNotFound to be %v", tt.notFound)
+			}
+		})
+	}
+}
+
+// Test the sync loop waits for the maximal backoff delay after an unauthorized sync
+func TestSyncLoopUnauthorized(t *testing.T) {
+	var syncs atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flags/sync" {
+			syncs.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(LoadFlagsResponse{Version: 1})
+	}))
+	defer server.Close()
+
+	client, err := MakeClient(context.Background(), server.URL, "test-project", Defaults{},
+		WithSyncInterval(time.Millisecond), WithBackoff(time.Millisecond, time.Hour),
+		WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer client.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for syncs.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if syncs.Load() != 1 {
+		t.Errorf("Expected a single sync before the maximal delay, got %d", syncs.Load())
+	}
+}
+
+// Test WithRequestCompression gzips requests and gzipped responses are decompressed
+func TestRequestCompression(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("Expected gzipped request, got Content-Encoding %q", r.Header.Get("Content-Encoding"))
+		}
+		body, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("Failed to read gzipped request: %v", err)
+			return
+		}
+		var req LoadFlagsRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil || req.Project != "test-project" {
+			t.Errorf("Unexpected request %+v: %v", req, err)
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		json.NewEncoder(gz).Encode(LoadFlagsResponse{
+			Version: 1,
+			Flags:   []FlagResponse{{Name: "test_flag", Enabled: true}},
+		})
+	}))
+	defer server.Close()
+
+	flags, err := MakeClient(context.Background(), server.URL, "test-project",
+		Defaults{Flags: []Flag{{Name: "test_flag"}}},
+		WithRequestCompression(), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	if !flags.Get("test_flag") {
+		t.Error("Expected test_flag from the gzipped response")
+	}
+}
+
+// Test responses larger than WithMaxResponseSize fail with ErrorResponseTooLarge
+func TestMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(LoadFlagsResponse{
+			Version: 1,
+			Flags:   []FlagResponse{{Name: strings.Repeat("x", 1000), Enabled: true}},
+		})
+	}))
+	defer server.Close()
+
+	_, err := MakeClient(context.Background(), server.URL, "test-project", Defaults{},
+		WithMaxResponseSize(100), WithLogger(&testLogger{}))
+	if !errors.Is(err, ErrorResponseTooLarge) {
+		t.Errorf("Expected ErrorResponseTooLarge, got %v", err)
+	}
+
+	flags, err := MakeClient(context.Background(), server.URL, "test-project", Defaults{},
+		WithMaxResponseSize(2000), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("Expected the response to fit, got %v", err)
+	}
+	flags.Close()
+}
+
+// Test State.Update drops entries which are neither declared nor returned by the server
+func TestStateUpdatePrune(t *testing.T) {
+	state := State{
+		version: 1,
+		flagState: map[string]FlagState{
+			"declared_flag": {Name: "declared_flag", Enabled: false},
+			"old_name":      {Name: "old_name", Enabled: true},
+		},
+		flagNames: []string{"declared_flag"},
+		valueState: map[string]ValueState{
+			"declared_value": {Name: "declared_value", Value: 1, DefaultValue: 1},
+			"old_value":      {Name: "old_value", Value: 2, IsOverridden: true},
+		},
+		valueNames: []string{"declared_value"},
+	}
+
+	// Server renamed old_name to new_name
+	state.Update(2, []FlagResponse{
+		{Name: "new_name", Enabled: true},
+	}, nil)
+
+	if _, exists := state.flagState["old_name"]; exists {
+		t.Error("Expected old_name to be pruned")
+	}
+	if _, exists := state.flagState["new_name"]; !exists {
+		t.Error("Expected new_name to be kept")
+	}
+	if _, exists := state.flagState["declared_flag"]; !exists {
+		t.Error("Expected declared_flag to be kept")
+	}
+	if _, exists := state.valueState["old_value"]; exists {
+		t.Error("Expected old_value to be pruned")
+	}
+	if _, exists := state.valueState["declared_value"]; !exists {
+		t.Error("Expected declared_value to be kept")
+	}
+}
+
+// Test updates replace the state without modifying the one seen by readers
+func TestUpdateCopyOnWrite(t *testing.T) {
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flags.state.Store(&State{
+		version: 1,
+		flagState: map[string]FlagState{
+			"some_flag": {Name: "some_flag", Enabled: false},
+		},
+		flagNames:  []string{"some_flag"},
+		valueState: map[string]ValueState{},
+	})
+
+	previous := flags.loadState()
+	if !flags.update(2, []FlagResponse{{Name: "some_flag", Enabled: true}}, nil) {
+		t.Fatal("Expected update to report a changed version")
+	}
+	if previous.version != 1 || previous.flagState["some_flag"].Enabled {
+		t.Error("Expected previous state not to be modified")
+	}
+	if flags.loadState().version != 2 || !flags.Get("some_flag") {
+		t.Error("Expected the new state to be published")
+	}
+	if flags.update(2, []FlagResponse{{Name: "some_flag", Enabled: false}}, nil) {
+		t.Error("Expected update with the same version to be ignored")
+	}
+
+	// Concurrent reads and updates, run with -race
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				flags.Get("some_flag")
+				flags.Snapshot()
+			}
+		}()
+	}
+	for version := 3; version < 100; version++ {
+		flags.update(version, []FlagResponse{{Name: "some_flag", Enabled: version%2 == 0}}, nil)
+	}
+	wg.Wait()
+}
+
+// Test LoadContext and SyncContext respect context cancellation
+func TestRequestContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SyncFlagsResponse{Version: 1})
+	}))
+	defer server.Close()
+	defer close(release)
+
+	flags := &FeatureFlags{
+		client:   server.Client(),
+		httpAddr: server.URL,
+		project:  "test-project",
+		logger:   &testLogger{},
+	}
+	flags.state.Store(&State{
+		flagState:  make(map[string]FlagState),
+		valueState: make(map[string]ValueState),
+	})
+
+	t.Run("sync with deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := flags.SyncContext(ctx)
+		if !errors.Is(err, ErrorCantSyncFlags) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded sync error, got %v", err)
+		}
+	})
+
+	t.Run("load with cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := flags.LoadContext(ctx)
+		if !errors.Is(err, ErrorCantLoadFlags) || !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected cancelled load error, got %v", err)
+		}
+	})
+
+	t.Run("close cancels in-flight request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		flags.ctx = ctx
+		flags.cancel = cancel
+		defer func() { flags.ctx, flags.cancel = nil, nil }()
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			flags.Close()
+		}()
+		if err := flags.SyncContext(context.Background()); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected cancelled sync error, got %v", err)
+		}
+	})
+}
+
+// Test sync loop backs off after failures and recovers after success
+func TestSyncLoopBackoff(t *testing.T) {
+	var available atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SyncFlagsResponse{Version: 1})
+	}))
+	defer server.Close()
+
+	available.Store(true)
+	client, err := MakeClient(
+		context.Background(),
+		server.URL,
+		"test-project",
+		Defaults{},
+		WithSyncInterval(time.Millisecond),
+		WithBackoff(time.Hour, time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer client.Close()
+
+	// Syncs on the interval while the server is available
+	deadline := time.Now().Add(time.Second)
+	for requests.Load() < 5 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected sync loop to sync on the interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A failure makes the loop wait for the backoff delay
+	available.Store(false)
+	failing := requests.Load() + 1
+	deadline = time.Now().Add(time.Second)
+	for requests.Load() < failing {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a failed sync")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	failed := requests.Load()
+	time.Sleep(20 * time.Millisecond)
+	if requests.Load() != failed {
+		t.Errorf("Expected no requests during backoff, got %d more", requests.Load()-failed)
+	}
+}
+
+// Test authentication options add headers to server requests
+func TestAuthOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []ClientOption
+		header string
+		value  string
+	}{
+		{
+			name:   "auth token",
+			opts:   []ClientOption{WithAuthToken("secret")},
+			header: "Authorization",
+			value:  "Bearer secret",
+		},
+		{
+			name:   "basic auth",
+			opts:   []ClientOption{WithBasicAuth("user", "pass")},
+			header: "Authorization",
+			value:  "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:   "custom header",
+			opts:   []ClientOption{WithRequestHeader("X-Api-Key", "key")},
+			header: "X-Api-Key",
+			value:  "key",
+		},
+		{
+			name:   "last option wins",
+			opts:   []ClientOption{WithAuthToken("old"), WithAuthToken("new")},
+			header: "Authorization",
+			value:  "Bearer new",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = append(received, r.Header.Get(tt.header))
+				if r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("Expected JSON content type, got %s", r.Header.Get("Content-Type"))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(LoadFlagsResponse{Version: 1})
+			}))
+			defer server.Close()
+
+			client, err := MakeClient(context.Background(), server.URL, "test-project", Defaults{}, tt.opts...)
+			if err != nil {
+				t.Fatalf("MakeClient failed: %v", err)
+			}
+			defer client.Close()
+
+			if err := client.Sync(); err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+			if len(received) != 2 {
+				t.Fatalf("Expected 2 requests, got %d", len(received))
+			}
+			for _, value := range received {
+				if value != tt.value {
+					t.Errorf("Expected %s header %q, got %q", tt.header, tt.value, value)
+				}
+			}
+		})
+	}
+}
+
+// Test MakeClient rejects invalid combinations of options before loading
+func TestOptionValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		httpAddr string
+		opts     []ClientOption
+	}{
+		{name: "missing address", opts: nil},
+		{
+			name: "auth with local source",
+			opts: []ClientOption{WithLocalSource("flags.json"), WithAuthToken("token")},
+		},
+		{
+			name: "circuit breaker with local source",
+			opts: []ClientOption{WithLocalSource("flags.json"), WithCircuitBreaker(3, time.Second)},
+		},
+		{
+			name: "state store with local source",
+			opts: []ClientOption{WithLocalSource("flags.json"), WithStateCache(t.TempDir())},
+		},
+		{
+			name:     "backoff max less than min",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithBackoff(time.Minute, time.Second)},
+		},
+		{
+			name:     "zero circuit breaker cool-down",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithCircuitBreaker(3, 0)},
+		},
+		{
+			name:     "unknown int coercion",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithIntCoercion(IntCoercion(10))},
+		},
+		{
+			name:     "unknown error action",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithErrorPolicy(ErrorPolicy{TypeMismatch: ErrorAction(10)})},
+		},
+		{
+			name:     "negative change history",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithChangeHistory(-1)},
+		},
+		{
+			name: "exposure events with local source",
+			opts: []ClientOption{WithLocalSource("flags.json"), WithExposureEvents(time.Second, 10)},
+		},
+		{
+			name:     "exposure events without buffer",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithExposureEvents(time.Second, 0)},
+		},
+		{
+			name: "sources with local source",
+			opts: []ClientOption{WithLocalSource("flags.json"), WithSources(ServerSource())},
+		},
+		{
+			name: "server source without address",
+			opts: []ClientOption{WithSources(ServerSource())},
+		},
+		{
+			name:     "shared cache with state store",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithSharedCache(newMemoryKV()), WithStateCache(t.TempDir())},
+		},
+		{
+			name: "shared cache with local source",
+			opts: []ClientOption{WithLocalSource("flags.json"), WithSharedCache(newMemoryKV())},
+		},
+		{
+			name:     "load retry without attempts",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithLoadRetry(0, time.Second)},
+		},
+		{
+			name:     "negative load retry backoff",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithLoadRetry(3, -time.Second)},
+		},
+		{
+			name:     "negative max response size",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithMaxResponseSize(-1)},
+		},
+		{
+			name: "request compression with local source",
+			opts: []ClientOption{WithLocalSource("flags.json"), WithRequestCompression()},
+		},
+		{
+			name:     "logger with slog",
+			httpAddr: "http://localhost",
+			opts:     []ClientOption{WithLogger(&testLogger{}), WithSlog(slog.Default())},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MakeClient(context.Background(), tt.httpAddr, "test-project", Defaults{}, tt.opts...)
+			if !errors.Is(err, ErrorInvalidOptions) {
+				t.Errorf("Expected ErrorInvalidOptions, got %v", err)
+			}
+		})
+	}
+}
+
+// Test WithEnvironment namespaces the project in requests
+func TestEnvironment(t *testing.T) {
+	var project string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req LoadFlagsRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		project = req.Project
+		json.NewEncoder(w).Encode(LoadFlagsResponse{Version: 1})
+	}))
+	defer server.Close()
+
+	client, err := MakeClient(context.Background(), server.URL, "test-project", Defaults{}, WithEnvironment("staging"))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if project != "test-project.staging" {
+		t.Errorf("Expected project test-project.staging, got %s", project)
+	}
+}
+
+// Test requests, updates and reads can run concurrently, run with -race.
+// Every snapshot must be consistent: the flag is enabled in even versions.
+func TestConcurrentSyncAndGet(t *testing.T) {
+	var version atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Flags are sent as names by both load and sync requests
+		var req struct {
+			Flags []string `json:"flags"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if len(req.Flags) != 1 || req.Flags[0] != "test_flag" {
+			t.Errorf("Unexpected flags in request: %v", req.Flags)
+		}
+		next := int(version.Add(1))
+		json.NewEncoder(w).Encode(LoadFlagsResponse{
+			Version: next,
+			Flags:   []FlagResponse{{Name: "test_flag", Enabled: next%2 == 0}},
+			Values:  []ValueResponse{{Name: "test_value", Value: next}},
+		})
+	}))
+	defer server.Close()
+
+	flags := &FeatureFlags{
+		client:   server.Client(),
+		httpAddr: server.URL,
+		project:  "test-project",
+		logger:   &testLogger{},
+	}
+	flags.state.Store(&State{
+		flagState:  map[string]FlagState{"test_flag": {Name: "test_flag"}},
+		flagNames:  []string{"test_flag"},
+		valueState: map[string]ValueState{"test_value": {Name: "test_value", Value: 0, DefaultValue: 0}},
+		valueNames: []string{"test_value"},
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := flags.Sync(); err != nil {
+					t.Errorf("Sync failed: %v", err)
+				}
+				if _, err := flags.LoadRequest(); err != nil {
+					t.Errorf("LoadRequest failed: %v", err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				flags.Get("test_flag")
+				flags.GetValue("test_value")
+				snapshot := flags.Snapshot()
+				if snapshot.Version > 0 && snapshot.Flags()["test_flag"].Enabled != (snapshot.Version%2 == 0) {
+					t.Errorf("Inconsistent snapshot at version %d", snapshot.Version)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
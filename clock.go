@@ -0,0 +1,101 @@
+package featureflags
+
+import "time"
+
+// Clock tells the time against which temporary overrides and activation windows of
+// flags are evaluated, see WithClock
+type Clock interface {
+	Now() time.Time
+}
+
+// TimerClock is a Clock which also schedules the publication of expirations and activation
+// windows, e.g. a fake clock calling f when a test moves it by d. The returned func prevents
+// the call like time.Timer.Stop.
+type TimerClock interface {
+	Clock
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// WithClock sets the clock used to evaluate temporary overrides and activation windows,
+// e.g. a fake clock in tests of time-boxed launches. The system clock is used by default.
+// Transitions are published by real timers unless the clock implements TimerClock.
+func WithClock(clock Clock) ClientOption {
+	return func(c *ClientConfig) {
+		c.clock = clock
+	}
+}
+
+// now returns the time of the clock, or of the system clock if clock is nil
+func now(clock Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
+
+// afterFunc calls f after d has passed on the clock, see TimerClock
+func afterFunc(clock Clock, d time.Duration, f func()) (stop func() bool) {
+	if clock, ok := clock.(TimerClock); ok {
+		return clock.AfterFunc(d, f)
+	}
+	return time.AfterFunc(d, f).Stop
+}
+
+// fixedClock is a clock stopped at a point in time
+type fixedClock time.Time
+
+func (clock fixedClock) Now() time.Time {
+	return time.Time(clock)
+}
+
+// publishedClock returns a clock stopped at the publication of the state, or the clock
+// of the state if it was never published
+func (state *State) publishedClock() Clock {
+	if state.published.IsZero() {
+		return state.clock
+	}
+	return fixedClock(state.published)
+}
+
+// nextTransition returns the earliest time after the publication of the state at which
+// a temporary override expires or an activation window starts or ends
+func (state *State) nextTransition() (next time.Time, ok bool) {
+	consider := func(t time.Time) {
+		if t.After(state.published) && (!ok || t.Before(next)) {
+			next, ok = t, true
+		}
+	}
+	for _, flag := range state.flagState {
+		consider(flag.ExpiresAt)
+		consider(flag.StartsAt)
+		consider(flag.EndsAt)
+	}
+	for _, value := range state.valueState {
+		consider(value.ExpiresAt)
+	}
+	return next, ok
+}
+
+// scheduleTransition arms a timer publishing the state again at its next transition, so
+// expirations and activation windows are delivered to watchers and recorded in the history
+// like changes made by the server. It must be called with flags.mu held.
+func (flags *FeatureFlags) scheduleTransition(state *State) {
+	if flags.transition != nil {
+		flags.transition()
+		flags.transition = nil
+	}
+	// Only clients created by MakeClient have a lifetime to bound the timer by
+	if flags.ctx == nil || flags.ctx.Err() != nil {
+		return
+	}
+	next, ok := state.nextTransition()
+	if !ok {
+		return
+	}
+	flags.transition = afterFunc(state.clock, next.Sub(now(state.clock)), flags.refresh)
+}
+
+// refresh publishes the state as of now, delivering changes made by the passage of time
+func (flags *FeatureFlags) refresh() {
+	flags.modify(func(*State) {})
+}
@@ -0,0 +1,220 @@
+package featureflags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a TimerClock which is moved by tests
+type fakeClock struct {
+	now atomic.Int64
+
+	mu     sync.Mutex
+	timers map[*fakeTimer]struct{}
+}
+
+type fakeTimer struct {
+	at time.Time
+	f  func()
+}
+
+func (c *fakeClock) Now() time.Time {
+	return time.Unix(0, c.now.Load())
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timers == nil {
+		c.timers = make(map[*fakeTimer]struct{})
+	}
+	timer := &fakeTimer{at: c.Now().Add(d), f: f}
+	c.timers[timer] = struct{}{}
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		_, ok := c.timers[timer]
+		delete(c.timers, timer)
+		return ok
+	}
+}
+
+// set moves the clock to t and calls the functions of the timers which are due
+func (c *fakeClock) set(t time.Time) {
+	c.now.Store(t.UnixNano())
+	c.mu.Lock()
+	var due []*fakeTimer
+	for timer := range c.timers {
+		if !timer.at.After(t) {
+			due = append(due, timer)
+			delete(c.timers, timer)
+		}
+	}
+	c.mu.Unlock()
+	for _, timer := range due {
+		timer.f()
+	}
+}
+
+// pending returns the number of timers which haven't fired or been stopped
+func (c *fakeClock) pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Test flags with activation windows use the default outside of the window
+func TestActivationWindow(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	path := filepath.Join(t.TempDir(), "flags.json")
+	content := `{"flags": [
+		{"name": "launch", "enabled": true, "payload": ["beta"], "starts_at": "2026-03-01T10:00:00Z"},
+		{"name": "maintenance", "enabled": true, "starts_at": "2026-03-01T10:00:00Z", "ends_at": "2026-03-01T11:00:00Z"},
+		{"name": "promo", "enabled": true, "ends_at": "2026-03-01T11:00:00Z"}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	clock := &fakeClock{}
+	clock.set(start.Add(-time.Minute))
+	flags, err := MakeClient(context.Background(), "", "test-project",
+		Defaults{Flags: []Flag{{Name: "launch"}, {Name: "maintenance"}, {Name: "promo"}}},
+		WithLocalSource(path), WithClock(clock), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		launch      bool
+		maintenance bool
+		promo       bool
+	}{
+		{"before the window", start.Add(-time.Minute), false, false, true},
+		{"at the start", start, true, true, true},
+		{"within the window", start.Add(30 * time.Minute), true, true, true},
+		{"at the end", end, true, false, false},
+		{"after the window", end.Add(time.Minute), true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.set(tt.now)
+			if got := flags.Get("launch"); got != tt.launch {
+				t.Errorf("Expected launch %v, got %v", tt.launch, got)
+			}
+			if got := flags.Get("maintenance"); got != tt.maintenance {
+				t.Errorf("Expected maintenance %v, got %v", tt.maintenance, got)
+			}
+			if got := flags.Get("promo"); got != tt.promo {
+				t.Errorf("Expected promo %v, got %v", tt.promo, got)
+			}
+			if _, enabled := flags.GetFlagPayload("launch"); enabled != tt.launch {
+				t.Errorf("Expected launch payload %v, got %v", tt.launch, enabled)
+			}
+		})
+	}
+}
+
+// Test expirations and activation windows are published as change sets when they pass
+func TestTimeTransitions(t *testing.T) {
+	expires := time.Now().Add(50 * time.Millisecond).UTC().Format(time.RFC3339Nano)
+	path := filepath.Join(t.TempDir(), "flags.json")
+	content := `{"version": 1, "flags": [
+		{"name": "temporary", "enabled": true, "expires_at": "` + expires + `"},
+		{"name": "launch", "enabled": true, "starts_at": "2100-01-01T00:00:00Z"}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	flags, err := MakeClient(context.Background(), "", "test-project",
+		Defaults{Flags: []Flag{{Name: "temporary"}, {Name: "launch"}}},
+		WithLocalSource(path), WithChangeHistory(10), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	changes, stop := flags.Watch("temporary")
+	defer stop()
+	select {
+	case change := <-changes:
+		if change.Enabled || !change.Previous {
+			t.Errorf("Expected temporary to revert to the default, got %+v", change)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a change when the temporary override expires")
+	}
+	history := flags.History()
+	if len(history) == 0 || history[len(history)-1].Changes[0].Name != "temporary" {
+		t.Errorf("Expected the expiration in the history, got %+v", history)
+	}
+
+	// The timer is armed for the start of the launch window
+	flags.mu.Lock()
+	armed := flags.transition != nil
+	flags.mu.Unlock()
+	if !armed {
+		t.Error("Expected a timer for the next activation window")
+	}
+
+	flags.Close()
+	flags.mu.Lock()
+	if flags.transition != nil && flags.transition() {
+		t.Error("Expected Close to stop the timer")
+	}
+	flags.mu.Unlock()
+}
+
+// Test transitions follow a TimerClock instead of real timers
+func TestTimerClockTransitions(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "flags.json")
+	content := `{"version": 1, "flags": [
+		{"name": "temporary", "enabled": true, "expires_at": "2026-03-01T10:05:00Z"},
+		{"name": "launch", "enabled": true, "starts_at": "2026-03-01T11:00:00Z"}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	clock := &fakeClock{}
+	clock.set(start)
+	flags, err := MakeClient(context.Background(), "", "test-project",
+		Defaults{Flags: []Flag{{Name: "temporary"}, {Name: "launch"}}},
+		WithLocalSource(path), WithClock(clock), WithChangeHistory(10), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	var changes []string
+	flags.OnChange(func(batch []FlagChange) {
+		for _, change := range batch {
+			changes = append(changes, change.Name)
+		}
+	})
+
+	clock.set(start.Add(10 * time.Minute))
+	if !reflect.DeepEqual(changes, []string{"temporary"}) {
+		t.Errorf("Expected the expiration when the clock passes it, got %v", changes)
+	}
+	clock.set(start.Add(2 * time.Hour))
+	if !reflect.DeepEqual(changes, []string{"temporary", "launch"}) {
+		t.Errorf("Expected the activation when the clock passes it, got %v", changes)
+	}
+	if clock.pending() != 0 {
+		t.Errorf("Expected no timer after the last transition, got %d", clock.pending())
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Manifest declares flags and values of a project
+type Manifest struct {
+	Flags  []ManifestFlag  `json:"flags"`
+	Values []ManifestValue `json:"values"`
+}
+
+type ManifestFlag struct {
+	Name        string `json:"name"`
+	Enabled     bool   `json:"enabled"`
+	Description string `json:"description,omitempty"`
+}
+
+type ManifestValue struct {
+	Name string `json:"name"`
+	// Type is one of int, string, bool or float64
+	Type        string          `json:"type"`
+	Value       json.RawMessage `json:"value"`
+	Description string          `json:"description,omitempty"`
+}
+
+// getters maps value types to getters of the client
+var getters = map[string]string{
+	"int":     "MustGetValueInt",
+	"string":  "MustGetValueString",
+	"bool":    "MustGetValueBool",
+	"float64": "MustGetValueFloat64",
+}
+
+type flagData struct {
+	Name        string
+	Enabled     bool
+	Description string
+	Method      string
+	Field       string
+}
+
+type valueData struct {
+	Name        string
+	Type        string
+	Literal     string
+	Description string
+	Method      string
+	Getter      string
+}
+
+type templateData struct {
+	Package  string
+	Manifest string
+	Flags    []flagData
+	Values   []valueData
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by featureflags-gen from {{.Manifest}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import featureflags "github.com/evo-company/featureflags-go"
+
+// Defaults holds flags and values declared in the manifest, pass it to featureflags.MakeClient
+var Defaults = featureflags.Defaults{
+	Flags: []featureflags.Flag{
+{{- range .Flags}}
+		{Name: {{printf "%q" .Name}}, Enabled: {{.Enabled}}},
+{{- end}}
+	},
+	Values: []featureflags.Value{
+{{- range .Values}}
+		{Name: {{printf "%q" .Name}}, Value: {{.Literal}}},
+{{- end}}
+	},
+}
+
+// Flags provides typed accessors for flags and values declared in the manifest
+type Flags struct {
+	client *featureflags.FeatureFlags
+{{- range .Flags}}
+	{{.Field}} *featureflags.FlagHandle
+{{- end}}
+}
+
+// New returns typed accessors for flags and values of the client
+func New(client *featureflags.FeatureFlags) *Flags {
+	return &Flags{
+		client: client,
+{{- range .Flags}}
+		{{.Field}}: client.Flag({{printf "%q" .Name}}),
+{{- end}}
+	}
+}
+{{range .Flags}}
+// {{.Method}} {{if .Description}}{{.Description}}{{else}}returns the state of the {{.Name}} flag{{end}}
+func (f *Flags) {{.Method}}() bool {
+	return f.{{.Field}}.Enabled()
+}
+{{end}}
+{{- range .Values}}
+// {{.Method}} {{if .Description}}{{.Description}}{{else}}returns the {{.Name}} value{{end}}
+func (f *Flags) {{.Method}}() {{.Type}} {
+	return f.client.{{.Getter}}({{printf "%q" .Name}})
+}
+{{end}}`))
+
+// generate returns Go source with defaults and typed accessors for the manifest
+func generate(manifest Manifest, pkg, source string) ([]byte, error) {
+	data := templateData{Package: pkg, Manifest: source}
+	methods := make(map[string]string)
+	declare := func(name string) (string, error) {
+		method := exportedName(name)
+		if method == "" {
+			return "", fmt.Errorf("name %q has no letters or digits", name)
+		}
+		if other, exists := methods[method]; exists {
+			return "", fmt.Errorf("names %q and %q both generate method %s", other, name, method)
+		}
+		methods[method] = name
+		return method, nil
+	}
+
+	for _, flag := range manifest.Flags {
+		method, err := declare(flag.Name)
+		if err != nil {
+			return nil, err
+		}
+		data.Flags = append(data.Flags, flagData{
+			Name:        flag.Name,
+			Enabled:     flag.Enabled,
+			Description: commentText(flag.Description),
+			Method:      method,
+			Field:       "flag" + method,
+		})
+	}
+
+	for _, value := range manifest.Values {
+		method, err := declare(value.Name)
+		if err != nil {
+			return nil, err
+		}
+		getter, ok := getters[value.Type]
+		if !ok {
+			return nil, fmt.Errorf("value %s has unsupported type %q", value.Name, value.Type)
+		}
+		literal, err := valueLiteral(value)
+		if err != nil {
+			return nil, err
+		}
+		data.Values = append(data.Values, valueData{
+			Name:        value.Name,
+			Type:        value.Type,
+			Literal:     literal,
+			Description: commentText(value.Description),
+			Method:      method,
+			Getter:      getter,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// valueLiteral returns a Go literal of the default value, which keeps its type
+// when stored in the interface{} of featureflags.Value
+func valueLiteral(value ManifestValue) (string, error) {
+	var decoded any
+	if err := json.Unmarshal(value.Value, &decoded); err != nil {
+		return "", fmt.Errorf("value %s has invalid default: %w", value.Name, err)
+	}
+
+	switch value.Type {
+	case "int":
+		if number, ok := decoded.(float64); ok && number == math.Trunc(number) {
+			return strconv.FormatInt(int64(number), 10), nil
+		}
+	case "float64":
+		if number, ok := decoded.(float64); ok {
+			literal := strconv.FormatFloat(number, 'g', -1, 64)
+			if !strings.ContainsAny(literal, ".e") {
+				literal += ".0"
+			}
+			return literal, nil
+		}
+	case "string":
+		if str, ok := decoded.(string); ok {
+			return strconv.Quote(str), nil
+		}
+	case "bool":
+		if b, ok := decoded.(bool); ok {
+			return strconv.FormatBool(b), nil
+		}
+	}
+	return "", fmt.Errorf("value %s default %s is not of type %s", value.Name, value.Value, value.Type)
+}
+
+// commentText collapses line breaks and other whitespace of a description into single
+// spaces, so it stays on the line of the doc comment it is inserted into
+func commentText(description string) string {
+	return strings.Join(strings.Fields(description), " ")
+}
+
+// exportedName converts flag names like new_checkout, new-checkout or NEW_CHECKOUT to NewCheckout
+func exportedName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteString("Flag")
+		}
+		if upper {
+			b.WriteRune(unicode.ToUpper(r))
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+		upper = false
+	}
+	return b.String()
+}
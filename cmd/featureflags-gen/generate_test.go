@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+// Test code generation from a manifest
+func TestGenerate(t *testing.T) {
+	manifest := Manifest{
+		Flags: []ManifestFlag{
+			{Name: "new_checkout", Enabled: true, Description: "enables the new checkout"},
+			{Name: "SEARCH-V2"},
+		},
+		Values: []ManifestValue{
+			{Name: "request_timeout", Type: "int", Value: json.RawMessage(`30`)},
+			{Name: "greeting", Type: "string", Value: json.RawMessage(`"hello"`)},
+			{Name: "ratio", Type: "float64", Value: json.RawMessage(`1`)},
+			{Name: "beta", Type: "bool", Value: json.RawMessage(`true`)},
+		},
+	}
+
+	source, err := generate(manifest, "flags", "flags.json")
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "flags_gen.go", source, 0); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, source)
+	}
+
+	for _, expected := range []string{
+		"// Code generated by featureflags-gen from flags.json. DO NOT EDIT.",
+		"package flags",
+		`{Name: "new_checkout", Enabled: true},`,
+		`{Name: "request_timeout", Value: 30},`,
+		`{Name: "greeting", Value: "hello"},`,
+		`{Name: "ratio", Value: 1.0},`,
+		"// NewCheckout enables the new checkout",
+		"func (f *Flags) NewCheckout() bool {",
+		"// SearchV2 returns the state of the SEARCH-V2 flag",
+		`client.Flag("SEARCH-V2"),`,
+		"func (f *Flags) RequestTimeout() int {",
+		`return f.client.MustGetValueInt("request_timeout")`,
+		"func (f *Flags) Ratio() float64 {",
+		"func (f *Flags) Beta() bool {",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("Expected generated code to contain %q\n%s", expected, source)
+		}
+	}
+}
+
+// Test multi-line descriptions are collapsed into a single comment line
+func TestGenerateMultilineDescription(t *testing.T) {
+	manifest := Manifest{
+		Flags: []ManifestFlag{
+			{Name: "new_checkout", Description: "enables the\n  new checkout\n}\n\nfunc init() { panic(1) }"},
+		},
+		Values: []ManifestValue{
+			{Name: "timeout", Type: "int", Value: json.RawMessage(`30`), Description: "returns the\r\ntimeout"},
+		},
+	}
+
+	source, err := generate(manifest, "flags", "flags.json")
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "flags_gen.go", source, 0); err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, source)
+	}
+	for _, expected := range []string{
+		"// NewCheckout enables the new checkout } func init() { panic(1) }\n",
+		"// Timeout returns the timeout\n",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("Expected generated code to contain %q\n%s", expected, source)
+		}
+	}
+	if strings.Contains(string(source), "\nfunc init()") {
+		t.Errorf("Expected the description not to inject code\n%s", source)
+	}
+}
+
+// Test invalid manifests are rejected
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest Manifest
+	}{
+		{
+			name:     "duplicate method",
+			manifest: Manifest{Flags: []ManifestFlag{{Name: "new_checkout"}, {Name: "NEW-CHECKOUT"}}},
+		},
+		{
+			name:     "empty name",
+			manifest: Manifest{Flags: []ManifestFlag{{Name: "__"}}},
+		},
+		{
+			name:     "unsupported type",
+			manifest: Manifest{Values: []ManifestValue{{Name: "items", Type: "[]string", Value: json.RawMessage(`[]`)}}},
+		},
+		{
+			name:     "mismatched default",
+			manifest: Manifest{Values: []ManifestValue{{Name: "timeout", Type: "int", Value: json.RawMessage(`1.5`)}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := generate(tt.manifest, "flags", "flags.json"); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+// Test flag names are converted to exported identifiers
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"new_checkout": "NewCheckout",
+		"new-checkout": "NewCheckout",
+		"NEW_CHECKOUT": "NewCheckout",
+		"user.id":      "UserId",
+		"2fa":          "Flag2fa",
+	}
+	for name, expected := range tests {
+		if got := exportedName(name); got != expected {
+			t.Errorf("exportedName(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Command featureflags-gen generates typed accessors and defaults for flags and values
+// declared in a JSON manifest:
+//
+//	{
+//	  "flags": [{"name": "new_checkout", "enabled": false, "description": "enables the new checkout"}],
+//	  "values": [{"name": "request_timeout", "type": "int", "value": 30}]
+//	}
+//
+// Supported value types are int, string, bool and float64. Use it with go generate:
+//
+//	//go:generate go run github.com/evo-company/featureflags-go/cmd/featureflags-gen -manifest flags.json -out flags_gen.go
+//
+// The generated file declares Defaults to pass to featureflags.MakeClient, and a Flags type
+// with a method per flag and value, e.g. NewCheckout() bool and RequestTimeout() int.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	var manifestPath, outPath, pkg string
+	flag.StringVar(&manifestPath, "manifest", "flags.json", "Path to the JSON manifest")
+	flag.StringVar(&outPath, "out", "flags_gen.go", "Path to the generated file")
+	flag.StringVar(&pkg, "package", os.Getenv("GOPACKAGE"), "Package name of the generated file (default: $GOPACKAGE)")
+	flag.Parse()
+
+	if err := run(manifestPath, outPath, pkg); err != nil {
+		fmt.Fprintf(os.Stderr, "featureflags-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(manifestPath, outPath, pkg string) error {
+	if pkg == "" {
+		return fmt.Errorf("package name is required outside of go generate, use -package")
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("invalid manifest %s: %w", manifestPath, err)
+	}
+
+	source, err := generate(manifest, pkg, filepath.Base(manifestPath))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, source, 0o644)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+// list prints flags and values of a single version of the state
+func list(client *featureflags.FeatureFlags, out io.Writer) error {
+	snapshot := client.Snapshot()
+	fmt.Fprintf(out, "version %d\n\n", snapshot.Version)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FLAG\tENABLED")
+	for _, name := range sortedKeys(snapshot.Flags()) {
+		fmt.Fprintf(w, "%s\t%t\n", name, snapshot.Get(name))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	values := snapshot.Values()
+	if len(values) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VALUE\tJSON")
+	for _, name := range sortedKeys(values) {
+		value, err := json.Marshal(snapshot.GetValue(name))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, value)
+	}
+	return w.Flush()
+}
+
+// eval prints whether the flag is enabled
+func eval(client *featureflags.FeatureFlags, name string, out io.Writer) error {
+	if _, exists := client.Snapshot().Flag(name); !exists {
+		return fmt.Errorf("unknown flag %s", name)
+	}
+	fmt.Fprintln(out, client.Get(name))
+	return nil
+}
+
+// get prints the value as JSON
+func get(client *featureflags.FeatureFlags, name string, out io.Writer) error {
+	if _, exists := client.Snapshot().Value(name); !exists {
+		return fmt.Errorf("unknown value %s", name)
+	}
+	value, err := json.Marshal(client.GetValue(name))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s\n", value)
+	return nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(featureflags.LoadFlagsResponse{
+			Version: 5,
+			Flags:   []featureflags.FlagResponse{{Name: "new_checkout", Enabled: true}},
+			Values:  []featureflags.ValueResponse{{Name: "request_timeout", Value: 30}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Test commands which load the project from the server
+func TestCommands(t *testing.T) {
+	server := newTestServer(t)
+	manifest := filepath.Join(t.TempDir(), "flags.json")
+	os.WriteFile(manifest, []byte(`{
+		"flags": [{"name": "new_checkout", "enabled": false}],
+		"values": [{"name": "request_timeout", "value": 10}]
+	}`), 0o644)
+
+	base := options{addr: server.URL, project: "test-project", token: "token"}
+	withManifest := base
+	withManifest.manifest = manifest
+	withNames := base
+	withNames.flags = "new_checkout, other_flag"
+	withNames.values = "request_timeout"
+
+	tests := []struct {
+		name string
+		opts options
+		args []string
+		want []string
+	}{
+		{name: "list", opts: withManifest, args: []string{"list"},
+			want: []string{"version 5", "new_checkout  true", "request_timeout  30"}},
+		{name: "list by names", opts: withNames, args: []string{"list"},
+			want: []string{"new_checkout  true", "other_flag    false\n"}},
+		{name: "eval", opts: withNames, args: []string{"eval", "new_checkout"}, want: []string{"true\n"}},
+		{name: "get", opts: withManifest, args: []string{"get", "request_timeout"}, want: []string{"30\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := run(context.Background(), tt.opts, tt.args, &out); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Expected %q in output:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+// Test invalid invocations are rejected
+func TestCommandErrors(t *testing.T) {
+	server := newTestServer(t)
+	opts := options{addr: server.URL, project: "test-project", token: "token", flags: "new_checkout"}
+
+	tests := []struct {
+		name string
+		opts options
+		args []string
+	}{
+		{name: "no command", opts: opts},
+		{name: "unknown command", opts: opts, args: []string{"delete"}},
+		{name: "eval without name", opts: opts, args: []string{"eval"}},
+		{name: "unknown flag", opts: opts, args: []string{"eval", "other_flag"}},
+		{name: "unknown value", opts: opts, args: []string{"get", "other_value"}},
+		{name: "no declarations", opts: options{addr: server.URL, project: "test-project"}, args: []string{"list"}},
+		{name: "no address", opts: options{project: "test-project", flags: "a"}, args: []string{"list"}},
+		{name: "unauthorized", opts: options{addr: server.URL, project: "test-project", flags: "a"}, args: []string{"list"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := run(context.Background(), tt.opts, tt.args, &bytes.Buffer{}); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+// Test queries sync the project, and only -register loads it
+func TestRegister(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		json.NewEncoder(w).Encode(featureflags.LoadFlagsResponse{Version: 1})
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		register bool
+		path     string
+	}{
+		{register: false, path: "/flags/sync"},
+		{register: true, path: "/flags/load"},
+	} {
+		paths = nil
+		opts := options{addr: server.URL, project: "test-project", flags: "new_chekout", register: tt.register}
+		if err := run(context.Background(), opts, []string{"list"}, &bytes.Buffer{}); err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+		if len(paths) != 1 || paths[0] != tt.path {
+			t.Errorf("Expected a request to %s with register %t, got %v", tt.path, tt.register, paths)
+		}
+	}
+}
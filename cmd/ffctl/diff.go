@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+// diffFiles prints changes between two state files in the load response format
+func diffFiles(oldPath, newPath string, out io.Writer) error {
+	previous, err := readState(oldPath)
+	if err != nil {
+		return err
+	}
+	current, err := readState(newPath)
+	if err != nil {
+		return err
+	}
+	for _, line := range diffStates(previous, current) {
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
+
+func readState(path string) (*featureflags.LoadFlagsResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state featureflags.LoadFlagsResponse
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// diffStates returns changes as lines sorted by kind and name:
+// "+ flag name=true", "- value name=1" and "~ flag name: false -> true"
+func diffStates(previous, current *featureflags.LoadFlagsResponse) []string {
+	lines := []string{fmt.Sprintf("version %d -> %d", previous.Version, current.Version)}
+
+	oldFlags, newFlags := make(map[string]any), make(map[string]any)
+	for _, flag := range previous.Flags {
+		oldFlags[flag.Name] = flag.Enabled
+	}
+	for _, flag := range current.Flags {
+		newFlags[flag.Name] = flag.Enabled
+	}
+	lines = append(lines, diffMaps("flag", oldFlags, newFlags)...)
+
+	oldValues, newValues := make(map[string]any), make(map[string]any)
+	for _, value := range previous.Values {
+		oldValues[value.Name] = value.Value
+	}
+	for _, value := range current.Values {
+		newValues[value.Name] = value.Value
+	}
+	return append(lines, diffMaps("value", oldValues, newValues)...)
+}
+
+func diffMaps(kind string, previous, current map[string]any) []string {
+	var lines []string
+	all := make(map[string]any, len(current))
+	for name := range previous {
+		all[name] = nil
+	}
+	for name := range current {
+		all[name] = nil
+	}
+	for _, name := range sortedKeys(all) {
+		old, existed := previous[name]
+		value, exists := current[name]
+		switch {
+		case !existed:
+			lines = append(lines, fmt.Sprintf("+ %s %s=%s", kind, name, formatValue(value)))
+		case !exists:
+			lines = append(lines, fmt.Sprintf("- %s %s=%s", kind, name, formatValue(old)))
+		case !reflect.DeepEqual(old, value):
+			lines = append(lines, fmt.Sprintf("~ %s %s: %s -> %s", kind, name, formatValue(old), formatValue(value)))
+		}
+	}
+	return lines
+}
+
+func formatValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+// Test diff lists added, removed and changed flags and values
+func TestDiffStates(t *testing.T) {
+	previous := &featureflags.LoadFlagsResponse{
+		Version: 1,
+		Flags: []featureflags.FlagResponse{
+			{Name: "kept_flag", Enabled: true},
+			{Name: "flipped_flag", Enabled: false},
+			{Name: "removed_flag", Enabled: true},
+		},
+		Values: []featureflags.ValueResponse{
+			{Name: "limit", Value: 10.0},
+			{Name: "tags", Value: []any{"a"}},
+		},
+	}
+	current := &featureflags.LoadFlagsResponse{
+		Version: 2,
+		Flags: []featureflags.FlagResponse{
+			{Name: "kept_flag", Enabled: true},
+			{Name: "flipped_flag", Enabled: true},
+			{Name: "added_flag", Enabled: false},
+		},
+		Values: []featureflags.ValueResponse{
+			{Name: "limit", Value: 20.0},
+			{Name: "tags", Value: []any{"a"}},
+		},
+	}
+
+	want := []string{
+		"version 1 -> 2",
+		"+ flag added_flag=false",
+		"~ flag flipped_flag: false -> true",
+		"- flag removed_flag=true",
+		"~ value limit: 10 -> 20",
+	}
+	got := diffStates(previous, current)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+// Test diff reads state files
+func TestDiffFiles(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.json")
+	newPath := filepath.Join(dir, "new.json")
+	os.WriteFile(oldPath, []byte(`{"version": 1, "flags": [{"name": "a", "enabled": false}]}`), 0o644)
+	os.WriteFile(newPath, []byte(`{"version": 2, "flags": [{"name": "a", "enabled": true}]}`), 0o644)
+
+	var out bytes.Buffer
+	if err := run(context.Background(), options{}, []string{"diff", oldPath, newPath}, &out); err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	if want := "version 1 -> 2\n~ flag a: false -> true\n"; out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+
+	if err := run(context.Background(), options{}, []string{"diff", oldPath, filepath.Join(dir, "missing.json")}, &out); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
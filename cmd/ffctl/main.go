@@ -0,0 +1,178 @@
+// Command ffctl queries a feature flags server from the command line:
+//
+//	ffctl -addr http://flags:8080 -project my-service -manifest flags.json list
+//	ffctl -addr http://flags:8080 -project my-service -flags new_checkout eval new_checkout
+//	ffctl -addr http://flags:8080 -project my-service -values request_timeout get request_timeout
+//	ffctl diff old-state.json new-state.json
+//
+// The server only returns flags and values the client declares, so list, eval and get
+// ask for the names from a manifest (the format of featureflags-gen) or from the -flags
+// and -values lists. Queries are read-only: they sync the project instead of loading it,
+// so a mistyped name keeps its default instead of being created on the server.
+// With -register the names are loaded like by any client, which registers them.
+// diff compares two state files in the load response format, e.g. files
+// of a state cache or of WithLocalSource, and doesn't contact the server.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+const usage = `Usage: ffctl [options] <command> [arguments]
+
+Commands:
+  list                 list flags and values with their state
+  eval <flag>          print whether the flag is enabled
+  get <value>          print the value as JSON
+  diff <old> <new>     print changes between two state files
+
+Options:
+`
+
+type options struct {
+	addr        string
+	project     string
+	environment string
+	token       string
+	manifest    string
+	flags       string
+	values      string
+	timeout     time.Duration
+	register    bool
+}
+
+func main() {
+	var opts options
+	fs := flag.NewFlagSet("ffctl", flag.ExitOnError)
+	fs.StringVar(&opts.addr, "addr", os.Getenv("FEATUREFLAGS_ADDR"), "Address of the feature flags server (default: $FEATUREFLAGS_ADDR)")
+	fs.StringVar(&opts.project, "project", os.Getenv("FEATUREFLAGS_PROJECT"), "Project name (default: $FEATUREFLAGS_PROJECT)")
+	fs.StringVar(&opts.environment, "env", "", "Environment of the project, see WithEnvironment")
+	fs.StringVar(&opts.token, "token", os.Getenv("FEATUREFLAGS_TOKEN"), "Bearer token (default: $FEATUREFLAGS_TOKEN)")
+	fs.StringVar(&opts.manifest, "manifest", "", "JSON manifest declaring flags and values")
+	fs.StringVar(&opts.flags, "flags", "", "Comma-separated flag names to declare")
+	fs.StringVar(&opts.values, "values", "", "Comma-separated value names to declare")
+	fs.BoolVar(&opts.register, "register", false, "Register the declared flags and values on the server, like a client loading them")
+	fs.DurationVar(&opts.timeout, "timeout", 10*time.Second, "Request timeout")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+
+	if err := run(context.Background(), opts, fs.Args(), os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "ffctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("command is required, see ffctl -h")
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "diff":
+		if len(args) != 2 {
+			return errors.New("diff takes two state files")
+		}
+		return diffFiles(args[0], args[1], out)
+	case "list":
+		if len(args) != 0 {
+			return errors.New("list takes no arguments")
+		}
+	case "eval", "get":
+		if len(args) != 1 {
+			return fmt.Errorf("%s takes a name", command)
+		}
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	client, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	switch command {
+	case "list":
+		return list(client, out)
+	case "eval":
+		return eval(client, args[0], out)
+	default:
+		return get(client, args[0], out)
+	}
+}
+
+// connect syncs the project with the declared flags and values, or loads it with -register
+func connect(ctx context.Context, opts options) (*featureflags.FeatureFlags, error) {
+	if opts.addr == "" || opts.project == "" {
+		return nil, errors.New("-addr and -project are required")
+	}
+	defaults, err := declare(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOpts := []featureflags.ClientOption{
+		featureflags.WithRequestTimeout(opts.timeout),
+		// The client is closed before the first sync, keep the loop idle
+		featureflags.WithSyncInterval(time.Hour),
+		featureflags.WithErrorPolicy(featureflags.LenientErrorPolicy),
+	}
+	if !opts.register {
+		clientOpts = append(clientOpts, featureflags.WithReadOnly())
+	}
+	if opts.environment != "" {
+		clientOpts = append(clientOpts, featureflags.WithEnvironment(opts.environment))
+	}
+	if opts.token != "" {
+		clientOpts = append(clientOpts, featureflags.WithAuthToken(opts.token))
+	}
+	return featureflags.MakeClient(ctx, opts.addr, opts.project, defaults, clientOpts...)
+}
+
+// declare returns defaults with flags and values from the manifest and the name lists
+func declare(opts options) (featureflags.Defaults, error) {
+	var defaults featureflags.Defaults
+	if opts.manifest != "" {
+		data, err := os.ReadFile(opts.manifest)
+		if err != nil {
+			return defaults, err
+		}
+		if err := json.Unmarshal(data, &defaults); err != nil {
+			return defaults, fmt.Errorf("invalid manifest %s: %w", opts.manifest, err)
+		}
+	}
+	for _, name := range splitNames(opts.flags) {
+		defaults.Flags = append(defaults.Flags, featureflags.Flag{Name: name})
+	}
+	for _, name := range splitNames(opts.values) {
+		defaults.Values = append(defaults.Values, featureflags.Value{Name: name})
+	}
+	if len(defaults.Flags) == 0 && len(defaults.Values) == 0 {
+		return defaults, errors.New("no flags or values declared, use -manifest, -flags or -values")
+	}
+	return defaults, nil
+}
+
+func splitNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
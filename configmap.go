@@ -0,0 +1,73 @@
+package featureflags
+
+import (
+	"context"
+	"time"
+)
+
+// configMapPollInterval is how often ConfigMapSource checks the file for changes
+var configMapPollInterval = time.Second
+
+// watchedSource is implemented by sources which notice their own changes, the client
+// syncs as soon as they report one instead of waiting for the sync interval. watch
+// starts watching in the background and returns.
+type watchedSource interface {
+	watch(ctx context.Context, changed func())
+}
+
+// configMapSource is a file source which watches a mounted ConfigMap or Secret
+type configMapSource struct {
+	*localSource
+}
+
+// ConfigMapSource reads the state from a file of a mounted Kubernetes ConfigMap or Secret,
+// in the same format as WithLocalSource. The file is checked every second and the client
+// syncs as soon as it changes. Kubelet updates mounted files by swapping a symlink, so
+// the path is resolved on every check. Files mounted with subPath are never updated.
+//
+// As the only source it drives the state wholly, e.g. in namespaces where the server is
+// unreachable. After ServerSource it bootstraps the client when the server is down:
+//
+//	featureflags.WithSources(
+//	    featureflags.ServerSource(),
+//	    featureflags.ConfigMapSource("/etc/featureflags/flags.json"),
+//	)
+func ConfigMapSource(path string) Source {
+	return configMapSource{&localSource{path: path}}
+}
+
+// watch calls changed on every change of the file, until ctx is done. A missing file
+// is not a change, so a deleted ConfigMap keeps the last state.
+func (src configMapSource) watch(ctx context.Context, changed func()) {
+	// Changes are detected against the file as it is now, before the client has read it
+	lastTarget, lastModTime, _ := src.stat()
+	go src.poll(ctx, changed, lastTarget, lastModTime)
+}
+
+func (src configMapSource) poll(ctx context.Context, changed func(), lastTarget string, lastModTime time.Time) {
+	ticker := time.NewTicker(configMapPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		target, modTime, err := src.stat()
+		if err != nil || (target == lastTarget && modTime.Equal(lastModTime)) {
+			continue
+		}
+		lastTarget, lastModTime = target, modTime
+		changed()
+	}
+}
+
+// watchSources starts watching the sources which notice their own changes
+func (flags *FeatureFlags) watchSources() {
+	for _, source := range flags.sources {
+		if watched, ok := source.(watchedSource); ok {
+			watched.watch(flags.context(), flags.TriggerSync)
+		}
+	}
+}
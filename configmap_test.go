@@ -0,0 +1,66 @@
+package featureflags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test ConfigMapSource syncs as soon as kubelet swaps the mounted files
+func TestConfigMapSource(t *testing.T) {
+	interval := configMapPollInterval
+	configMapPollInterval = 10 * time.Millisecond
+	defer func() { configMapPollInterval = interval }()
+
+	// Lay out the mount like kubelet: flags.json -> ..data/flags.json, ..data -> ..<timestamp>
+	dir := t.TempDir()
+	modTime := time.Now()
+	writeVersion := func(name, content string) {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		path := filepath.Join(dir, name, "flags.json")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		// Same modification time, so only the symlink swap tells the change
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set file times: %v", err)
+		}
+		tmp := filepath.Join(dir, "..data_tmp")
+		if err := os.Symlink(name, tmp); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, "..data")); err != nil {
+			t.Fatalf("Failed to swap symlink: %v", err)
+		}
+	}
+	writeVersion("..2026_01", `{"flags": [{"name": "test_flag", "enabled": false}]}`)
+	if err := os.Symlink(filepath.Join("..data", "flags.json"), filepath.Join(dir, "flags.json")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	flags, err := MakeClient(context.Background(), "", "test-project",
+		Defaults{Flags: []Flag{{Name: "test_flag"}}},
+		WithSources(ConfigMapSource(filepath.Join(dir, "flags.json"))),
+		WithSyncInterval(time.Hour), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	if flags.Get("test_flag") {
+		t.Fatal("Expected test_flag to be disabled")
+	}
+
+	writeVersion("..2026_02", `{"flags": [{"name": "test_flag", "enabled": true}]}`)
+	deadline := time.Now().Add(time.Second)
+	for !flags.Get("test_flag") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !flags.Get("test_flag") {
+		t.Error("Expected test_flag to be enabled after the ConfigMap update")
+	}
+}
@@ -0,0 +1,130 @@
+package featureflags
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Constraint validates a value set on the server. Values violating constraints
+// are rejected and the default value is used instead.
+type Constraint func(value interface{}) error
+
+// ConstraintViolation describes a server value rejected by a constraint
+type ConstraintViolation struct {
+	Name  string
+	Value interface{}
+	Err   error
+}
+
+// Min requires a numeric value greater than or equal to min
+func Min(min float64) Constraint {
+	return func(value interface{}) error {
+		number, ok := toFloat64(value)
+		if !ok {
+			return fmt.Errorf("%v is not a number", value)
+		}
+		if number < min {
+			return fmt.Errorf("%v is less than %v", value, min)
+		}
+		return nil
+	}
+}
+
+// Max requires a numeric value less than or equal to max
+func Max(max float64) Constraint {
+	return func(value interface{}) error {
+		number, ok := toFloat64(value)
+		if !ok {
+			return fmt.Errorf("%v is not a number", value)
+		}
+		if number > max {
+			return fmt.Errorf("%v is greater than %v", value, max)
+		}
+		return nil
+	}
+}
+
+// OneOf requires a value equal to one of allowed values. Numbers are compared
+// by value, so an int allowed value matches a float64 decoded from JSON.
+func OneOf(allowed ...interface{}) Constraint {
+	return func(value interface{}) error {
+		for _, candidate := range allowed {
+			if equalValues(value, candidate) {
+				return nil
+			}
+		}
+		options := make([]string, len(allowed))
+		for i, candidate := range allowed {
+			options[i] = fmt.Sprint(candidate)
+		}
+		return fmt.Errorf("%v is not one of [%s]", value, strings.Join(options, ", "))
+	}
+}
+
+func equalValues(left, right interface{}) bool {
+	leftNumber, leftOk := toFloat64(left)
+	rightNumber, rightOk := toFloat64(right)
+	if leftOk && rightOk {
+		return leftNumber == rightNumber
+	}
+	if leftOk || rightOk {
+		return false
+	}
+
+	// Only compare comparable scalars, maps and slices never match
+	switch left.(type) {
+	case string, bool:
+		return left == right
+	default:
+		return false
+	}
+}
+
+// checkConstraints returns the first constraint error for the value
+func checkConstraints(value interface{}, constraints []Constraint) error {
+	for _, constraint := range constraints {
+		if err := constraint(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IntValue declares an int value with optional constraints
+func IntValue(name string, value int, constraints ...Constraint) Value {
+	return Value{Name: name, Value: value, Constraints: constraints}
+}
+
+// StringValue declares a string value with optional constraints
+func StringValue(name string, value string, constraints ...Constraint) Value {
+	return Value{Name: name, Value: value, Constraints: constraints}
+}
+
+// ConstraintViolations returns server values rejected by constraints during the last update.
+func (flags *FeatureFlags) ConstraintViolations() []ConstraintViolation {
+	state := flags.loadState()
+
+	violations := make([]ConstraintViolation, len(state.violations))
+	copy(violations, state.violations)
+	return violations
+}
+
+// newViolations returns violations which were not reported by the previous state, so a
+// rejected value is logged once instead of on every sync
+func newViolations(previous, current []ConstraintViolation) []ConstraintViolation {
+	var violations []ConstraintViolation
+	for _, violation := range current {
+		reported := false
+		for _, old := range previous {
+			if old.Name == violation.Name && reflect.DeepEqual(old.Value, violation.Value) {
+				reported = true
+				break
+			}
+		}
+		if !reported {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
@@ -0,0 +1,138 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test constraint functions
+func TestConstraints(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint Constraint
+		value      interface{}
+		valid      bool
+	}{
+		{name: "min valid", constraint: Min(1), value: 1.0, valid: true},
+		{name: "min invalid", constraint: Min(1), value: 0, valid: false},
+		{name: "min not a number", constraint: Min(1), value: "10", valid: false},
+		{name: "max valid", constraint: Max(300), value: 300, valid: true},
+		{name: "max invalid", constraint: Max(300), value: 301.0, valid: false},
+		{name: "one of string", constraint: OneOf("a", "b"), value: "b", valid: true},
+		{name: "one of string invalid", constraint: OneOf("a", "b"), value: "c", valid: false},
+		{name: "one of number", constraint: OneOf(1, 2), value: 2.0, valid: true},
+		{name: "one of mixed types", constraint: OneOf("1"), value: 1.0, valid: false},
+		{name: "one of uncomparable", constraint: OneOf("a"), value: []interface{}{"a"}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.constraint(tt.value)
+			if tt.valid && err != nil {
+				t.Errorf("Expected %v to be valid, got %v", tt.value, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("Expected %v to be invalid", tt.value)
+			}
+		})
+	}
+}
+
+// Test server values violating constraints fall back to defaults
+func TestConstraintsEnforced(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := LoadFlagsResponse{
+			Version: 1,
+			Values: []ValueResponse{
+				{Name: "timeout", Value: 0.0},
+				{Name: "retries", Value: 5.0},
+				{Name: "mode", Value: "turbo"},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	defaults := Defaults{
+		Values: []Value{
+			IntValue("timeout", 30, Min(1), Max(300)),
+			IntValue("retries", 3, Min(0), Max(10)),
+			StringValue("mode", "safe", OneOf("safe", "fast")),
+		},
+	}
+
+	client, err := MakeClient(
+		context.Background(),
+		server.URL,
+		"test-project",
+		defaults,
+		WithLogger(&testLogger{}),
+	)
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if val := client.MustGetValueInt("timeout"); val != 30 {
+		t.Errorf("Expected default timeout 30, got %d", val)
+	}
+	if client.IsValueOverridden("timeout") {
+		t.Error("Expected rejected timeout not to be overridden")
+	}
+	if val := client.MustGetValueInt("retries"); val != 5 {
+		t.Errorf("Expected retries 5 from server, got %d", val)
+	}
+	if val := client.MustGetValueString("mode"); val != "safe" {
+		t.Errorf("Expected default mode 'safe', got %s", val)
+	}
+
+	violations := client.ConstraintViolations()
+	if len(violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %v", violations)
+	}
+	if violations[0].Name != "timeout" || violations[1].Name != "mode" {
+		t.Errorf("Unexpected violations: %v", violations)
+	}
+}
+
+// Test a rejected server value is logged once, not on every sync
+func TestConstraintViolationsLoggedOnce(t *testing.T) {
+	logger := &printLogger{}
+	flags := &FeatureFlags{logger: logger}
+	flags.state.Store(&State{
+		version: 1,
+		valueState: map[string]ValueState{
+			"timeout": {Name: "timeout", Value: 30, DefaultValue: 30, constraints: []Constraint{Min(1)}},
+		},
+		valueNames: []string{"timeout"},
+	})
+
+	updates := []struct {
+		version int
+		value   float64
+		logged  int
+	}{
+		{version: 2, value: 0, logged: 1},
+		{version: 2, value: 0, logged: 1},  // same version
+		{version: 3, value: 0, logged: 1},  // same violation
+		{version: 4, value: -1, logged: 2}, // another rejected value
+	}
+	for _, update := range updates {
+		flags.update(update.version, nil, []ValueResponse{{Name: "timeout", Value: update.value}})
+		logged := 0
+		for _, message := range logger.messages {
+			if strings.Contains(message, "rejected") {
+				logged++
+			}
+		}
+		if logged != update.logged {
+			t.Errorf("Expected %d logged violations after version %d, got %v",
+				update.logged, update.version, logger.messages)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Sources of the current state of a flag or a value, reported by DebugHandler
+const (
+	debugSourceDefault  = "default"  // the default from code, the server hasn't sent the flag or value
+	debugSourceServer   = "server"   // the state sent by the server
+	debugSourceExpiring = "expiring" // a temporary server override which is still active
+	debugSourceExpired  = "expired"  // a temporary server override has expired, the default is used
+	debugSourceOverride = "override" // a local override, see Override and OverrideValue
+	debugSourceInactive = "inactive" // outside the activation window of the flag, the default is used
+)
+
+type debugFlag struct {
+	Name      string     `json:"name"`
+	Enabled   bool       `json:"enabled"`
+	Default   bool       `json:"default"`
+	Source    string     `json:"source"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	Payload   []string   `json:"payload,omitempty"`
+}
+
+type debugValue struct {
+	Name      string      `json:"name"`
+	Value     interface{} `json:"value"`
+	Default   interface{} `json:"default"`
+	Source    string      `json:"source"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+}
+
+type debugState struct {
+	Project  string       `json:"project"`
+	Version  int          `json:"version"`
+	Sequence uint64       `json:"sequence"`
+	LastSync *time.Time   `json:"last_sync,omitempty"`
+	Flags    []debugFlag  `json:"flags"`
+	Values   []debugValue `json:"values"`
+	// History lists recent changes, newest first, see WithChangeHistory
+	History []debugChange `json:"history,omitempty"`
+}
+
+type debugFlagChange struct {
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+	Previous bool   `json:"previous"`
+}
+
+type debugValueChange struct {
+	Name     string      `json:"name"`
+	Value    interface{} `json:"value"`
+	Previous interface{} `json:"previous"`
+}
+
+// debugChange is a change set of the history, see WithChangeHistory
+type debugChange struct {
+	Sequence uint64             `json:"sequence"`
+	Version  int                `json:"version"`
+	Time     time.Time          `json:"time"`
+	Flags    []debugFlagChange  `json:"flags,omitempty"`
+	Values   []debugValueChange `json:"values,omitempty"`
+}
+
+// DebugHandler returns an http.Handler which renders the current state as JSON: the version,
+// the time of the last sync, and every flag and value with its default and the source of
+// its current state (default, server, expiring, expired, inactive or override). The "name"
+// query parameter limits the output to a single flag or value, e.g. to find out why it is off.
+// With WithChangeHistory, the output also lists recent changes, newest first.
+//
+// The handler exposes the configuration of the service, mount it on an internal listener.
+func (flags *FeatureFlags) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		debug := flags.debugState(r.URL.Query().Get("name"))
+		if r.URL.Query().Has("name") && len(debug.Flags) == 0 && len(debug.Values) == 0 {
+			http.Error(w, "flag or value not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(debug)
+	})
+}
+
+// debugState describes flags and values from a single version of the state,
+// only the named ones if name is not empty
+func (flags *FeatureFlags) debugState(name string) debugState {
+	state := flags.loadState()
+	debug := debugState{
+		Project:  flags.project,
+		Version:  state.version,
+		Sequence: state.sequence,
+		Flags:    []debugFlag{},
+		Values:   []debugValue{},
+	}
+	if lastSync := flags.LastSync(); !lastSync.IsZero() {
+		debug.LastSync = &lastSync
+	}
+
+	for _, flag := range state.flagState {
+		if name != "" && flag.Name != name {
+			continue
+		}
+		source := debugSource(state, flag.overridden, flag.ExpiresAt)
+		if source == debugSourceServer && !flag.fromServer {
+			// Declared in defaults, but never sent by the server
+			source = debugSourceDefault
+		}
+		if (source == debugSourceServer || source == debugSourceExpiring) && !flag.active(state.clock) {
+			source = debugSourceInactive
+		}
+		debug.Flags = append(debug.Flags, debugFlag{
+			Name:      flag.Name,
+			Enabled:   flag.current(state.clock),
+			Default:   flag.DefaultEnabled,
+			Source:    source,
+			ExpiresAt: debugTime(flag.ExpiresAt),
+			StartsAt:  debugTime(flag.StartsAt),
+			EndsAt:    debugTime(flag.EndsAt),
+			Payload:   flag.currentPayload(state.clock),
+		})
+	}
+	for _, value := range state.valueState {
+		if name != "" && value.Name != name {
+			continue
+		}
+		source := debugSource(state, value.overridden, value.ExpiresAt)
+		if source == debugSourceServer && !value.IsOverridden {
+			// The server value was rejected by constraints or not sent
+			source = debugSourceDefault
+		}
+		debug.Values = append(debug.Values, debugValue{
+			Name:      value.Name,
+			Value:     value.current(state.clock),
+			Default:   value.DefaultValue,
+			Source:    source,
+			ExpiresAt: debugTime(value.ExpiresAt),
+		})
+	}
+
+	sort.Slice(debug.Flags, func(i, j int) bool { return debug.Flags[i].Name < debug.Flags[j].Name })
+	sort.Slice(debug.Values, func(i, j int) bool { return debug.Values[i].Name < debug.Values[j].Name })
+	debug.History = debugHistory(flags.History(), name)
+	return debug
+}
+
+// debugHistory describes flag and value changes of the history, newest first,
+// only changes of the named flag or value if name is not empty
+func debugHistory(history []ChangeSet, name string) []debugChange {
+	var changes []debugChange
+	for i := len(history) - 1; i >= 0; i-- {
+		set := history[i]
+		change := debugChange{Sequence: set.Sequence, Version: set.Version, Time: set.Time}
+		for _, flag := range set.Changes {
+			if name == "" || flag.Name == name {
+				change.Flags = append(change.Flags, debugFlagChange{
+					Name: flag.Name, Enabled: flag.Enabled, Previous: flag.Previous,
+				})
+			}
+		}
+		for _, value := range set.Values {
+			if name == "" || value.Name == name {
+				change.Values = append(change.Values, debugValueChange{
+					Name: value.Name, Value: value.Value, Previous: value.Previous,
+				})
+			}
+		}
+		if len(change.Flags) > 0 || len(change.Values) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func debugSource(state *State, overridden bool, expiresAt time.Time) string {
+	switch {
+	case overridden:
+		return debugSourceOverride
+	case expired(state.clock, expiresAt):
+		return debugSourceExpired
+	case !expiresAt.IsZero():
+		return debugSourceExpiring
+	case state.version == 0:
+		return debugSourceDefault
+	default:
+		return debugSourceServer
+	}
+}
+
+func debugTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
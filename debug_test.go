@@ -0,0 +1,148 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newDebugFlags() *FeatureFlags {
+	flags := &FeatureFlags{project: "test-project", logger: &testLogger{}}
+	past := time.Now().Add(-time.Hour)
+	flags.state.Store(&State{
+		version:  3,
+		sequence: 2,
+		flagState: map[string]FlagState{
+			"server_flag":     {Name: "server_flag", Enabled: true, fromServer: true},
+			"default_flag":    {Name: "default_flag"},
+			"expired_flag":    {Name: "expired_flag", Enabled: true, DefaultEnabled: false, ExpiresAt: past, fromServer: true},
+			"overridden_flag": {Name: "overridden_flag", overridden: true, override: true},
+			"scheduled_flag":  {Name: "scheduled_flag", Enabled: true, StartsAt: time.Now().Add(time.Hour), fromServer: true},
+		},
+		valueState: map[string]ValueState{
+			"server_value":   {Name: "server_value", Value: "new", DefaultValue: "old", IsOverridden: true},
+			"rejected_value": {Name: "rejected_value", Value: 1, DefaultValue: 1},
+		},
+	})
+	return flags
+}
+
+// Test the debug handler renders flags and values with the source of their state
+func TestDebugHandler(t *testing.T) {
+	flags := newDebugFlags()
+	rec := httptest.NewRecorder()
+	flags.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/flags", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var debug debugState
+	if err := json.NewDecoder(rec.Body).Decode(&debug); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if debug.Project != "test-project" || debug.Version != 3 || debug.Sequence != 2 || debug.LastSync != nil {
+		t.Errorf("Unexpected state: %+v", debug)
+	}
+
+	flagSources := map[string]string{
+		"default_flag":    debugSourceDefault,
+		"expired_flag":    debugSourceExpired,
+		"overridden_flag": debugSourceOverride,
+		"scheduled_flag":  debugSourceInactive,
+		"server_flag":     debugSourceServer,
+	}
+	if len(debug.Flags) != len(flagSources) {
+		t.Fatalf("Expected %d flags, got %+v", len(flagSources), debug.Flags)
+	}
+	for _, flag := range debug.Flags {
+		if flag.Source != flagSources[flag.Name] {
+			t.Errorf("Expected %s source of %s, got %s", flagSources[flag.Name], flag.Name, flag.Source)
+		}
+	}
+	if debug.Flags[1].Name != "expired_flag" || debug.Flags[1].Enabled || debug.Flags[1].ExpiresAt == nil {
+		t.Errorf("Unexpected expired flag: %+v", debug.Flags[1])
+	}
+
+	valueSources := map[string]string{
+		"rejected_value": debugSourceDefault,
+		"server_value":   debugSourceServer,
+	}
+	for _, value := range debug.Values {
+		if value.Source != valueSources[value.Name] {
+			t.Errorf("Expected %s source of %s, got %s", valueSources[value.Name], value.Name, value.Source)
+		}
+	}
+}
+
+// Test the name parameter limits the output to a single flag or value
+func TestDebugHandlerName(t *testing.T) {
+	flags := newDebugFlags()
+
+	tests := []struct {
+		url    string
+		status int
+		flags  int
+		values int
+	}{
+		{url: "/?name=server_flag", status: http.StatusOK, flags: 1},
+		{url: "/?name=server_value", status: http.StatusOK, values: 1},
+		{url: "/?name=unknown", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			flags.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var debug debugState
+			if err := json.NewDecoder(rec.Body).Decode(&debug); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(debug.Flags) != tt.flags || len(debug.Values) != tt.values {
+				t.Errorf("Expected %d flags and %d values, got %+v", tt.flags, tt.values, debug)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	flags.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for POST, got %d", rec.Code)
+	}
+}
+
+// Test the debug handler lists the history of changes, filtered by name
+func TestDebugHandlerHistory(t *testing.T) {
+	flags := newDebugFlags()
+	flags.watchers.historySize = 10
+	flags.update(4, []FlagResponse{{Name: "server_flag", Enabled: false}}, nil)
+	flags.Override("overridden_flag", false)
+
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{"", []string{"overridden_flag", "server_flag"}},
+		{"server_flag", []string{"server_flag"}},
+	}
+	for _, tt := range tests {
+		debug := flags.debugState(tt.name)
+		var names []string
+		for _, change := range debug.History {
+			for _, flag := range change.Flags {
+				names = append(names, flag.Name)
+			}
+		}
+		if !reflect.DeepEqual(names, tt.flags) {
+			t.Errorf("Expected history of %v for %q, got %v", tt.flags, tt.name, names)
+		}
+	}
+}
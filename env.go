@@ -0,0 +1,84 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// envName converts a flag or value name to the environment variable suffix,
+// e.g. new-checkout becomes NEW_CHECKOUT
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
+
+// applyEnvOverrides overrides declared flags and values from environment variables
+// named prefix followed by the name, see WithEnvOverrides.
+func (flags *FeatureFlags) applyEnvOverrides(prefix string) error {
+	state := flags.loadState()
+	flagNames := make(map[string]string, len(state.flagNames))
+	for _, name := range state.flagNames {
+		flagNames[envName(name)] = name
+	}
+	valueNames := make(map[string]string, len(state.valueNames))
+	for _, name := range state.valueNames {
+		valueNames[envName(name)] = name
+	}
+
+	for _, env := range os.Environ() {
+		key, raw, _ := strings.Cut(env, "=")
+		suffix, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+
+		if name, ok := flagNames[suffix]; ok {
+			enabled, err := strconv.ParseBool(raw)
+			if err != nil {
+				return fmt.Errorf("%s is not a bool: %w", key, err)
+			}
+			flags.Override(name, enabled)
+			flags.logger.Printf("Flag %s is overridden by %s", name, key)
+			continue
+		}
+		if name, ok := valueNames[suffix]; ok {
+			value, err := parseEnvValue(raw, state.valueState[name].DefaultValue)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			flags.OverrideValue(name, value)
+			flags.logger.Printf("Value %s is overridden by %s", name, key)
+			continue
+		}
+		flags.logger.Printf("Environment variable %s does not match any declared flag or value", key)
+	}
+	return nil
+}
+
+// parseEnvValue parses the raw value according to the type of the default
+func parseEnvValue(raw string, defaultValue any) (any, error) {
+	switch defaultValue.(type) {
+	case string:
+		return raw, nil
+	case int:
+		return strconv.Atoi(raw)
+	case float64:
+		return strconv.ParseFloat(raw, 64)
+	case bool:
+		return strconv.ParseBool(raw)
+	default:
+		var value any
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("not a JSON value: %w", err)
+		}
+		return value, nil
+	}
+}
@@ -0,0 +1,64 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test WithEnvOverrides overrides declared flags and values from the environment
+func TestEnvOverrides(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(LoadFlagsResponse{
+			Version: 1,
+			Flags:   []FlagResponse{{Name: "new-checkout", Enabled: false}},
+			Values:  []ValueResponse{{Name: "timeout", Value: 10.0}},
+		})
+	}))
+	defer server.Close()
+
+	defaults := Defaults{
+		Flags: []Flag{{Name: "new-checkout", Enabled: false}},
+		Values: []Value{
+			{Name: "timeout", Value: 30},
+			{Name: "greeting", Value: "hello"},
+		},
+	}
+
+	t.Run("overrides", func(t *testing.T) {
+		t.Setenv("FF_OVERRIDE_NEW_CHECKOUT", "true")
+		t.Setenv("FF_OVERRIDE_TIMEOUT", "5")
+		t.Setenv("FF_OVERRIDE_GREETING", "hi")
+		t.Setenv("FF_OVERRIDE_UNKNOWN", "true")
+
+		client, err := MakeClient(context.Background(), server.URL, "test-project", defaults,
+			WithEnvOverrides("FF_OVERRIDE_"), WithLogger(&testLogger{}))
+		if err != nil {
+			t.Fatalf("MakeClient failed: %v", err)
+		}
+		defer client.Close()
+
+		if !client.Get("new-checkout") {
+			t.Error("Expected new-checkout to be overridden to true")
+		}
+		if val := client.MustGetValueInt("timeout"); val != 5 {
+			t.Errorf("Expected timeout to be overridden to 5, got %d", val)
+		}
+		if val := client.MustGetValueString("greeting"); val != "hi" {
+			t.Errorf("Expected greeting to be overridden to hi, got %s", val)
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		t.Setenv("FF_OVERRIDE_TIMEOUT", "soon")
+
+		_, err := MakeClient(context.Background(), server.URL, "test-project", defaults,
+			WithEnvOverrides("FF_OVERRIDE_"))
+		if !errors.Is(err, ErrorInvalidOptions) {
+			t.Errorf("Expected ErrorInvalidOptions, got %v", err)
+		}
+	})
+}
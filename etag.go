@@ -0,0 +1,34 @@
+package featureflags
+
+import (
+	"errors"
+	"sync"
+)
+
+// errNotModified is returned by requests which the server answered with 304 Not Modified
+var errNotModified = errors.New("not modified")
+
+// syncETag remembers the ETag of the last server response together with the version
+// of the state it describes. The ETag is only sent while the client is at that version,
+// so a state updated from another source is never reported as up to date.
+type syncETag struct {
+	mu      sync.Mutex
+	version int
+	value   string
+}
+
+func (e *syncETag) get(version int) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.version != version {
+		return ""
+	}
+	return e.value
+}
+
+func (e *syncETag) set(version int, value string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.version = version
+	e.value = value
+}
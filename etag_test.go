@@ -0,0 +1,93 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// Test syncs send the ETag of the current version and handle 304 Not Modified
+func TestSyncETag(t *testing.T) {
+	var mu sync.Mutex
+	version := 1
+	var ifNoneMatch []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		etag := `"v` + strconv.Itoa(version) + `"`
+		if r.URL.Path == "/flags/sync" {
+			ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("ETag", etag)
+		json.NewEncoder(w).Encode(LoadFlagsResponse{
+			Version: version,
+			Flags:   []FlagResponse{{Name: "test_flag", Enabled: version > 1}},
+		})
+	}))
+	defer server.Close()
+
+	flags := &FeatureFlags{
+		client:   server.Client(),
+		httpAddr: server.URL,
+		project:  "test-project",
+		logger:   &testLogger{},
+	}
+	flags.state.Store(&State{
+		flagState: map[string]FlagState{"test_flag": {Name: "test_flag"}},
+		flagNames: []string{"test_flag"},
+	})
+
+	if err := flags.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if flags.loadState().version != 1 || flags.Get("test_flag") {
+		t.Errorf("Expected unchanged state after 304")
+	}
+
+	mu.Lock()
+	version = 2
+	mu.Unlock()
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if flags.loadState().version != 2 || !flags.Get("test_flag") {
+		t.Errorf("Expected state of version 2")
+	}
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	want := []string{`"v1"`, `"v1"`, `"v2"`}
+	if len(ifNoneMatch) != len(want) {
+		t.Fatalf("Expected %d syncs, got %v", len(want), ifNoneMatch)
+	}
+	for i := range want {
+		if ifNoneMatch[i] != want[i] {
+			t.Errorf("Expected If-None-Match %s on sync %d, got %q", want[i], i, ifNoneMatch[i])
+		}
+	}
+}
+
+// Test the ETag is only returned for the version it was received with
+func TestSyncETagVersion(t *testing.T) {
+	var etag syncETag
+	etag.set(3, `"v3"`)
+
+	if got := etag.get(3); got != `"v3"` {
+		t.Errorf("Expected ETag of version 3, got %q", got)
+	}
+	if got := etag.get(4); got != "" {
+		t.Errorf("Expected no ETag for another version, got %q", got)
+	}
+}
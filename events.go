@@ -0,0 +1,118 @@
+package featureflags
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// exposureReporter buffers evaluations and posts them to /flags/events in batches.
+// It is registered as an evaluation hook, so it sees every Get and value getter call.
+type exposureReporter struct {
+	flags     *FeatureFlags
+	interval  time.Duration
+	maxEvents int
+
+	mu      sync.Mutex
+	events  []ExposureEvent
+	dropped int
+
+	full chan struct{} // signals the buffer has reached maxEvents
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newExposureReporter(flags *FeatureFlags, interval time.Duration, maxEvents int) *exposureReporter {
+	return &exposureReporter{
+		flags:     flags,
+		interval:  interval,
+		maxEvents: maxEvents,
+		full:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (r *exposureReporter) AfterEvaluation(evaluation Evaluation) {
+	kind := "flag"
+	if evaluation.Kind == EvaluationValue {
+		kind = "value"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.events) >= r.maxEvents {
+		r.dropped++
+		return
+	}
+	r.events = append(r.events, ExposureEvent{
+		Kind:      kind,
+		Name:      evaluation.Name,
+		Result:    evaluation.Result,
+		Timestamp: time.Now(),
+	})
+	if len(r.events) == r.maxEvents {
+		select {
+		case r.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run flushes events every interval or as soon as the buffer is full, until
+// close is called or the client context is done
+func (r *exposureReporter) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	ctx := r.flags.context()
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.full:
+		case <-r.stop:
+			// The client is closing, send what is left before the context is cancelled
+			r.flush(ctx)
+			return
+		case <-ctx.Done():
+			return
+		}
+		r.flush(ctx)
+	}
+}
+
+// flush posts buffered events to the server. Events of a failed batch are dropped,
+// so a server without the events endpoint doesn't make the buffer grow.
+func (r *exposureReporter) flush(ctx context.Context) {
+	r.mu.Lock()
+	events, dropped := r.events, r.dropped
+	r.events, r.dropped = nil, 0
+	r.mu.Unlock()
+
+	if len(events) == 0 && dropped == 0 {
+		return
+	}
+
+	req := ReportEventsRequest{
+		Project: r.flags.project,
+		Events:  events,
+		Dropped: dropped,
+	}
+	httpReq, err := r.flags.newRequest(ctx, "/flags/events", req)
+	if err == nil {
+		_, err = r.flags.do(httpReq, nil)
+	}
+	if err != nil {
+		r.flags.logEvent(slog.LevelWarn, "Could not report exposure events", err,
+			slog.Int("events", len(events)), slog.Int("dropped", dropped))
+	}
+}
+
+// close flushes remaining events and waits for the reporter to exit
+func (r *exposureReporter) close() {
+	close(r.stop)
+	<-r.done
+}
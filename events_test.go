@@ -0,0 +1,138 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventsServer serves load and sync requests and records reported event batches
+type eventsServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	batches []ReportEventsRequest
+}
+
+func newEventsServer(t *testing.T) *eventsServer {
+	s := &eventsServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/flags/events" {
+			json.NewEncoder(w).Encode(LoadFlagsResponse{
+				Version: 1,
+				Flags:   []FlagResponse{{Name: "test_flag", Enabled: true}},
+			})
+			return
+		}
+		var req ReportEventsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode events: %v", err)
+		}
+		s.mu.Lock()
+		s.batches = append(s.batches, req)
+		s.mu.Unlock()
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *eventsServer) received() []ReportEventsRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ReportEventsRequest(nil), s.batches...)
+}
+
+// Test remaining exposure events are sent on Close
+func TestExposureEventsFlushOnClose(t *testing.T) {
+	server := newEventsServer(t)
+	flags, err := MakeClient(context.Background(), server.URL, "test-project",
+		Defaults{Flags: []Flag{{Name: "test_flag"}}, Values: []Value{{Name: "test_value", Value: "default"}}},
+		WithSyncInterval(time.Hour), WithExposureEvents(time.Hour, 100))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+
+	flags.Get("test_flag")
+	flags.GetValue("test_value")
+	flags.Close()
+
+	batches := server.received()
+	if len(batches) != 1 {
+		t.Fatalf("Expected 1 batch, got %d", len(batches))
+	}
+	batch := batches[0]
+	if batch.Project != "test-project" || len(batch.Events) != 2 {
+		t.Fatalf("Unexpected batch: %+v", batch)
+	}
+	if event := batch.Events[0]; event.Kind != "flag" || event.Name != "test_flag" || event.Result != true {
+		t.Errorf("Unexpected flag event: %+v", event)
+	}
+	if event := batch.Events[1]; event.Kind != "value" || event.Name != "test_value" || event.Result != "default" {
+		t.Errorf("Unexpected value event: %+v", event)
+	}
+	if batch.Events[0].Timestamp.IsZero() {
+		t.Error("Expected event timestamp to be set")
+	}
+}
+
+// Test a full buffer is flushed early and events beyond it are counted as dropped
+func TestExposureEventsBufferCap(t *testing.T) {
+	server := newEventsServer(t)
+	flags := &FeatureFlags{
+		client:   server.Client(),
+		httpAddr: server.URL,
+		project:  "test-project",
+		logger:   &testLogger{},
+	}
+	reporter := newExposureReporter(flags, time.Hour, 2)
+	flags.hooks = []EvaluationHook{reporter}
+
+	for i := 0; i < 5; i++ {
+		flags.Get("test_flag")
+	}
+	select {
+	case <-reporter.full:
+	default:
+		t.Fatal("Expected full buffer to be signalled")
+	}
+
+	reporter.flush(context.Background())
+	batches := server.received()
+	if len(batches) != 1 {
+		t.Fatalf("Expected 1 batch, got %d", len(batches))
+	}
+	if len(batches[0].Events) != 2 || batches[0].Dropped != 3 {
+		t.Errorf("Expected 2 events and 3 dropped, got %d and %d", len(batches[0].Events), batches[0].Dropped)
+	}
+
+	// The buffer is empty after a flush, so nothing is sent
+	reporter.flush(context.Background())
+	if len(server.received()) != 1 {
+		t.Errorf("Expected no batch for an empty buffer")
+	}
+}
+
+// Test events are flushed on the interval
+func TestExposureEventsInterval(t *testing.T) {
+	server := newEventsServer(t)
+	flags, err := MakeClient(context.Background(), server.URL, "test-project",
+		Defaults{Flags: []Flag{{Name: "test_flag"}}},
+		WithSyncInterval(time.Hour), WithExposureEvents(10*time.Millisecond, 100))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	flags.Get("test_flag")
+	deadline := time.Now().Add(time.Second)
+	for len(server.received()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(server.received()) != 1 {
+		t.Errorf("Expected 1 batch, got %d", len(server.received()))
+	}
+}
@@ -0,0 +1,75 @@
+// Command cli is a one-shot command which prints flags and values.
+// It shows starting from defaults when the server is unavailable, and closing
+// the client before exiting.
+//
+//	go run ./example/cli -offline example/flags.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+var defaults = featureflags.Defaults{
+	Flags: []featureflags.Flag{
+		{Name: "new_checkout", Enabled: false},
+	},
+	Values: []featureflags.Value{
+		{Name: "greeting", Value: "Hello"},
+	},
+}
+
+// run prints states of all flags and values, sorted by name
+func run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("cli", flag.ContinueOnError)
+	host := fs.String("host", "https://flags.example.com", "Feature flags server")
+	offline := fs.String("offline", "", "Read flags from a JSON file instead of the server")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// With fail-open the command works with defaults when the server is unavailable
+	opts := []featureflags.ClientOption{featureflags.WithFailOpen()}
+	if *offline != "" {
+		opts = append(opts, featureflags.WithLocalSource(*offline))
+	}
+	flags, err := featureflags.MakeClient(context.Background(), *host, "example.cli", defaults, opts...)
+	if err != nil {
+		return err
+	}
+	defer flags.Close()
+
+	states := flags.Snapshot().Flags()
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "flag %s: %v\n", name, flags.Get(name))
+	}
+
+	values := flags.EvaluateAllValues()
+	names = names[:0]
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "value %s: %v\n", name, values[name])
+	}
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test the command prints flags from the example flags file
+func TestRun(t *testing.T) {
+	var out strings.Builder
+	if err := run([]string{"-offline", "../flags.json"}, &out); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	expected := `flag new_checkout: true
+flag worker_paused: false
+value batch_size: 5
+value greeting: Hello from flags.json
+`
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+// Test the command falls back to defaults when the server is unavailable
+func TestRunFailOpen(t *testing.T) {
+	var out strings.Builder
+	if err := run([]string{"-host", "http://127.0.0.1:1"}, &out); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	expected := `flag new_checkout: false
+value greeting: Hello
+`
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,98 @@
+// Command httpservice is an HTTP service which checks flags on every request.
+// It shows typed flag handles, passing the client to handlers through a middleware,
+// and graceful shutdown with Close.
+//
+//	go run ./example/httpservice -offline example/flags.json
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+var defaults = featureflags.Defaults{
+	Flags: []featureflags.Flag{
+		{Name: "new_checkout", Enabled: false},
+	},
+	Values: []featureflags.Value{
+		{Name: "greeting", Value: "Hello"},
+	},
+}
+
+type contextKey struct{}
+
+// withFlags makes the client available to handlers through the request context
+func withFlags(flags *featureflags.FeatureFlags, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), contextKey{}, flags)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// flagsFrom returns the client set by withFlags
+func flagsFrom(ctx context.Context) *featureflags.FeatureFlags {
+	return ctx.Value(contextKey{}).(*featureflags.FeatureFlags)
+}
+
+// newHandler returns the service handler. Flag handles are created once
+// and checked on every request without hashing flag names.
+func newHandler(flags *featureflags.FeatureFlags) http.Handler {
+	newCheckout := flags.Flag("new_checkout")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/checkout", func(w http.ResponseWriter, r *http.Request) {
+		if newCheckout.Enabled() {
+			fmt.Fprintln(w, "new checkout")
+			return
+		}
+		fmt.Fprintln(w, "old checkout")
+	})
+	mux.HandleFunc("/greeting", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, flagsFrom(r.Context()).MustGetValueString("greeting"))
+	})
+	return withFlags(flags, mux)
+}
+
+func main() {
+	var host, offline, addr string
+	flag.StringVar(&host, "host", "https://flags.example.com", "Feature flags server")
+	flag.StringVar(&offline, "offline", "", "Read flags from a JSON file instead of the server")
+	flag.StringVar(&addr, "addr", ":8080", "Address to listen on")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	opts := []featureflags.ClientOption{featureflags.WithLogger(log.Default()), featureflags.WithFailOpen()}
+	if offline != "" {
+		opts = append(opts, featureflags.WithLocalSource(offline))
+	}
+	flags, err := featureflags.MakeClient(ctx, host, "example.httpservice", defaults, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Stops the sync loop after the server has shut down
+	defer flags.Close()
+
+	server := &http.Server{Addr: addr, Handler: newHandler(flags)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Listening on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
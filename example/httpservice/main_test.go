@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+// Test handlers with flags read from the example flags file
+func TestHandler(t *testing.T) {
+	flags, err := featureflags.MakeClient(context.Background(), "", "example.httpservice", defaults,
+		featureflags.WithLocalSource("../flags.json"))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	server := httptest.NewServer(newHandler(flags))
+	defer server.Close()
+
+	tests := map[string]string{
+		"/checkout": "new checkout\n",
+		"/greeting": "Hello from flags.json\n",
+	}
+	for path, expected := range tests {
+		res, err := server.Client().Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if string(body) != expected {
+			t.Errorf("Expected %q from %s, got %q", expected, path, body)
+		}
+	}
+
+	// Local overrides are picked up by handles on the next request
+	flags.Override("new_checkout", false)
+	res, err := server.Client().Get(server.URL + "/checkout")
+	if err != nil {
+		t.Fatalf("GET /checkout failed: %v", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "old checkout\n" {
+		t.Errorf("Expected old checkout after override, got %q", body)
+	}
+}
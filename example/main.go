@@ -17,7 +17,7 @@ type (
 
 const TypeNumber = featureflags.TypeNumber
 
-var SomeFlag = Flag{"some_flag", false}
+var SomeFlag = Flag{Name: "some_flag", Enabled: false}
 
 var defaults = Defaults{
 	Flags: []Flag{
@@ -51,5 +51,7 @@ func main() {
 	if err != nil {
 		panic(err.Error())
 	}
+	defer flags.Close()
+
 	log.Printf("TEST_FLAG: %v", flags.Get("TEST_FLAG"))
 }
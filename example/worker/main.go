@@ -0,0 +1,80 @@
+// Command worker processes batches in a loop, which is paused by a flag.
+// It shows watching flag changes instead of polling, and stopping the client
+// together with the worker on shutdown.
+//
+//	go run ./example/worker -offline example/flags.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+var defaults = featureflags.Defaults{
+	Flags: []featureflags.Flag{
+		{Name: "worker_paused", Enabled: false},
+	},
+	Values: []featureflags.Value{
+		featureflags.IntValue("batch_size", 10, featureflags.Min(1), featureflags.Max(1000)),
+	},
+}
+
+// run processes batches every interval until ctx is done, skipping them while
+// the worker_paused flag is enabled.
+func run(ctx context.Context, flags *featureflags.FeatureFlags, interval time.Duration, out io.Writer) {
+	paused := flags.Get("worker_paused")
+	changes, stop := flags.Watch("worker_paused")
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change := <-changes:
+			paused = change.Enabled
+			fmt.Fprintf(out, "paused: %v\n", paused)
+		case <-ticker.C:
+			if paused {
+				continue
+			}
+			fmt.Fprintf(out, "processing batch of %d\n", flags.MustGetValueInt("batch_size"))
+		}
+	}
+}
+
+func main() {
+	var host, offline string
+	flag.StringVar(&host, "host", "https://flags.example.com", "Feature flags server")
+	flag.StringVar(&offline, "offline", "", "Read flags from a JSON file instead of the server")
+	flag.Parse()
+
+	// The sync loop stops when ctx is done, Close waits for it to exit
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	opts := []featureflags.ClientOption{
+		featureflags.WithLogger(log.Default()),
+		featureflags.WithSyncInterval(time.Second),
+	}
+	if offline != "" {
+		opts = append(opts, featureflags.WithLocalSource(offline))
+	}
+	flags, err := featureflags.MakeClient(ctx, host, "example.worker", defaults, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer flags.Close()
+
+	run(ctx, flags, time.Second, os.Stdout)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+// syncBuffer is a strings.Builder safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// Test the worker processes batches and pauses when the flag is enabled
+func TestRun(t *testing.T) {
+	flags, err := featureflags.MakeClient(context.Background(), "", "example.worker", defaults,
+		featureflags.WithLocalSource("../flags.json"))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(ctx, flags, time.Millisecond, &out)
+	}()
+
+	waitFor := func(text string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(out.String(), text) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected output to contain %q, got %q", text, out.String())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitFor("processing batch of 5")
+	flags.Override("worker_paused", true)
+	waitFor("paused: true")
+
+	cancel()
+	<-done
+}
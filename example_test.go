@@ -0,0 +1,97 @@
+package featureflags_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+var defaults = featureflags.Defaults{
+	Flags: []featureflags.Flag{
+		{Name: "new_checkout", Enabled: false},
+	},
+	Values: []featureflags.Value{
+		{Name: "http_timeout", Value: 30},
+	},
+}
+
+// newOfflineClient returns a client reading flags from a temporary file
+func newOfflineClient(contents string) (*featureflags.FeatureFlags, func()) {
+	dir, err := os.MkdirTemp("", "featureflags")
+	if err != nil {
+		panic(err)
+	}
+	path := filepath.Join(dir, "flags.json")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		panic(err)
+	}
+
+	flags, err := featureflags.MakeClient(context.Background(), "", "example", defaults,
+		featureflags.WithLocalSource(path))
+	if err != nil {
+		panic(err)
+	}
+	return flags, func() {
+		flags.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func ExampleWithLocalSource() {
+	flags, cleanup := newOfflineClient(`{
+		"flags": [{"name": "new_checkout", "enabled": true}],
+		"values": [{"name": "http_timeout", "value": 50}]
+	}`)
+	defer cleanup()
+
+	fmt.Println(flags.Get("new_checkout"))
+	fmt.Println(flags.MustGetValueInt("http_timeout"))
+	// Output:
+	// true
+	// 50
+}
+
+func ExampleFeatureFlags_Flag() {
+	flags, cleanup := newOfflineClient(`{"flags": [{"name": "new_checkout", "enabled": true}]}`)
+	defer cleanup()
+
+	// Create handles once, e.g. in a package-level variable or a constructor
+	newCheckout := flags.Flag("new_checkout")
+
+	if newCheckout.Enabled() {
+		fmt.Println("new checkout")
+	}
+	// Output: new checkout
+}
+
+func ExampleFeatureFlags_Override() {
+	flags, cleanup := newOfflineClient(`{"flags": [{"name": "new_checkout", "enabled": true}]}`)
+	defer cleanup()
+
+	flags.Override("new_checkout", false)
+	fmt.Println(flags.Get("new_checkout"))
+
+	flags.ClearOverrides()
+	fmt.Println(flags.Get("new_checkout"))
+	// Output:
+	// false
+	// true
+}
+
+func ExampleFeatureFlags_OnChange() {
+	flags, cleanup := newOfflineClient(`{"flags": [{"name": "new_checkout", "enabled": false}]}`)
+	defer cleanup()
+
+	unregister := flags.OnChange(func(changes []featureflags.FlagChange) {
+		for _, change := range changes {
+			fmt.Printf("%s: %v -> %v\n", change.Name, change.Previous, change.Enabled)
+		}
+	})
+	defer unregister()
+
+	flags.Override("new_checkout", true)
+	// Output: new_checkout: false -> true
+}
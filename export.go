@@ -0,0 +1,104 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// launchDarklyFile is the simplified LaunchDarkly flag file format
+// supported by the file data source of LaunchDarkly SDKs
+type launchDarklyFile struct {
+	FlagValues map[string]any `json:"flagValues"`
+}
+
+// unleashExport is the Unleash feature toggles export format
+type unleashExport struct {
+	Version  int              `json:"version"`
+	Features []unleashFeature `json:"features"`
+}
+
+type unleashFeature struct {
+	Name       string            `json:"name"`
+	Enabled    bool              `json:"enabled"`
+	Strategies []unleashStrategy `json:"strategies"`
+	Variants   []unleashVariant  `json:"variants,omitempty"`
+}
+
+type unleashStrategy struct {
+	Name string `json:"name"`
+}
+
+type unleashVariant struct {
+	Name    string         `json:"name"`
+	Weight  int            `json:"weight"`
+	Payload unleashPayload `json:"payload"`
+}
+
+type unleashPayload struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// ExportLaunchDarkly writes current flags and values in the LaunchDarkly flag file format:
+//
+//	{"flagValues": {"some_flag": true, "some_value": 42}}
+func (flags *FeatureFlags) ExportLaunchDarkly(w io.Writer) error {
+	state := flags.loadState()
+	file := launchDarklyFile{
+		FlagValues: make(map[string]any, len(state.flagState)+len(state.valueState)),
+	}
+	for name, flag := range state.flagState {
+		file.FlagValues[name] = flag.current(state.clock)
+	}
+	for name, value := range state.valueState {
+		file.FlagValues[name] = value.current(state.clock)
+	}
+
+	return writeJSON(w, file)
+}
+
+// ExportUnleash writes current flags and values in the Unleash feature toggles export format.
+// Flags become features with the default strategy. Values become enabled features with
+// a single variant, whose JSON payload holds the value.
+func (flags *FeatureFlags) ExportUnleash(w io.Writer) error {
+	state := flags.loadState()
+	export := unleashExport{
+		Version:  1,
+		Features: make([]unleashFeature, 0, len(state.flagState)+len(state.valueState)),
+	}
+	for name, flag := range state.flagState {
+		export.Features = append(export.Features, unleashFeature{
+			Name:       name,
+			Enabled:    flag.current(state.clock),
+			Strategies: []unleashStrategy{{Name: "default"}},
+		})
+	}
+	for name, value := range state.valueState {
+		payload, err := json.Marshal(value.current(state.clock))
+		if err != nil {
+			return err
+		}
+		export.Features = append(export.Features, unleashFeature{
+			Name:       name,
+			Enabled:    true,
+			Strategies: []unleashStrategy{{Name: "default"}},
+			Variants: []unleashVariant{{
+				Name:    name,
+				Weight:  1000,
+				Payload: unleashPayload{Type: "json", Value: string(payload)},
+			}},
+		})
+	}
+
+	sort.Slice(export.Features, func(i, j int) bool {
+		return export.Features[i].Name < export.Features[j].Name
+	})
+	return writeJSON(w, export)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
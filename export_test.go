@@ -0,0 +1,72 @@
+package featureflags
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func newExportFlags() *FeatureFlags {
+	flags := &FeatureFlags{}
+	flags.state.Store(&State{
+		flagState: map[string]FlagState{
+			"enabled_flag":  {Name: "enabled_flag", Enabled: true},
+			"disabled_flag": {Name: "disabled_flag", Enabled: false},
+		},
+		valueState: map[string]ValueState{
+			"timeout": {Name: "timeout", Value: 30, DefaultValue: 30},
+		},
+	})
+	return flags
+}
+
+// Test export to LaunchDarkly flag file format
+func TestExportLaunchDarkly(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newExportFlags().ExportLaunchDarkly(&buf); err != nil {
+		t.Fatalf("ExportLaunchDarkly failed: %v", err)
+	}
+
+	var file struct {
+		FlagValues map[string]any `json:"flagValues"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &file); err != nil {
+		t.Fatalf("Failed to decode export: %v", err)
+	}
+	if file.FlagValues["enabled_flag"] != true || file.FlagValues["disabled_flag"] != false {
+		t.Errorf("Unexpected flag values: %v", file.FlagValues)
+	}
+	if file.FlagValues["timeout"] != 30.0 {
+		t.Errorf("Expected timeout 30, got %v", file.FlagValues["timeout"])
+	}
+}
+
+// Test export to Unleash feature toggles format
+func TestExportUnleash(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newExportFlags().ExportUnleash(&buf); err != nil {
+		t.Fatalf("ExportUnleash failed: %v", err)
+	}
+
+	var export unleashExport
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatalf("Failed to decode export: %v", err)
+	}
+	if len(export.Features) != 3 {
+		t.Fatalf("Expected 3 features, got %d", len(export.Features))
+	}
+
+	names := []string{"disabled_flag", "enabled_flag", "timeout"}
+	for i, feature := range export.Features {
+		if feature.Name != names[i] {
+			t.Errorf("Expected feature %s, got %s", names[i], feature.Name)
+		}
+	}
+	if !export.Features[1].Enabled || export.Features[0].Enabled {
+		t.Error("Expected flag states to be exported")
+	}
+	timeout := export.Features[2]
+	if len(timeout.Variants) != 1 || timeout.Variants[0].Payload.Value != "30" {
+		t.Errorf("Expected value as variant payload, got %+v", timeout.Variants)
+	}
+}
@@ -0,0 +1,112 @@
+package fftest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+// FakeClient is a real client connected to a fake Server, so it behaves exactly
+// like FeatureFlags. Changes made by SetFlag and SetValue are visible immediately.
+//
+//	flags := fftest.NewFakeClient(t, defaults)
+//	flags.SetFlag("new_checkout", true)
+//	handler := NewHandler(flags) // accepts featureflags.Client
+type FakeClient struct {
+	*featureflags.FeatureFlags
+	Server *Server
+
+	t testing.TB
+}
+
+var _ featureflags.Client = (*FakeClient)(nil)
+
+// NewFakeClient returns a client with the defaults, connected to a new fake server.
+// The background sync is disabled, the client is closed when the test finishes.
+func NewFakeClient(t testing.TB, defaults featureflags.Defaults, opts ...featureflags.ClientOption) *FakeClient {
+	t.Helper()
+	server := NewServer(t)
+	opts = append([]featureflags.ClientOption{featureflags.WithSyncInterval(time.Hour)}, opts...)
+	client, err := featureflags.MakeClient(context.Background(), server.URL, "fftest", defaults, opts...)
+	if err != nil {
+		t.Fatalf("fftest: could not create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return &FakeClient{FeatureFlags: client, Server: server, t: t}
+}
+
+// SetFlag sets the flag on the server and syncs the client
+func (c *FakeClient) SetFlag(name string, enabled bool) {
+	c.t.Helper()
+	c.Server.SetFlag(name, enabled)
+	c.sync()
+}
+
+// SetValue sets the value on the server and syncs the client
+func (c *FakeClient) SetValue(name string, value any) {
+	c.t.Helper()
+	c.Server.SetValue(name, value)
+	c.sync()
+}
+
+func (c *FakeClient) sync() {
+	c.t.Helper()
+	if err := c.Sync(); err != nil {
+		c.t.Errorf("fftest: could not sync client: %v", err)
+	}
+}
+
+// overrider is implemented by *featureflags.FeatureFlags and *FakeClient
+type overrider interface {
+	Override(name string, enabled bool)
+	LocalOverride(name string) (enabled, ok bool)
+	ClearOverride(name string)
+}
+
+// serial reports whether the test isn't parallel. t.Setenv panics in parallel tests
+// and makes t.Parallel panic afterwards, the variable is restored when the test finishes.
+func serial(t testing.TB) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	t.Setenv("FFTEST_FORCE", "1")
+	return true
+}
+
+// Force overrides the flag on the client until the test finishes, so both branches of
+// a flag can be tested with table-driven tests:
+//
+//	for _, enabled := range []bool{true, false} {
+//		t.Run(fmt.Sprint(enabled), func(t *testing.T) {
+//			fftest.Force(t, flags, "new_checkout", enabled)
+//			...
+//		})
+//	}
+//
+// When the test finishes, the override the flag had before is restored, e.g. one forced
+// by the parent test or set by WithEnvOverrides, or the override is removed.
+//
+// The override applies to the whole client, not only to the test: every goroutine using
+// the client sees it. Like t.Setenv, Force fails the test if it or its parent is parallel.
+// Parallel tests can instead force the flag in a context with ForceContext of the client,
+// read by GetContext, or give each test its own client, e.g. from NewFakeClient.
+func Force(t testing.TB, flags overrider, name string, enabled bool) {
+	t.Helper()
+	if !serial(t) {
+		t.Fatalf("fftest: Force of %s can not be used in parallel tests, use ForceContext", name)
+		return
+	}
+	previous, overridden := flags.LocalOverride(name)
+	flags.Override(name, enabled)
+	t.Cleanup(func() {
+		if overridden {
+			flags.Override(name, previous)
+		} else {
+			flags.ClearOverride(name)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package fftest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+var defaults = featureflags.Defaults{
+	Flags: []featureflags.Flag{
+		{Name: "new_checkout", Enabled: false},
+	},
+	Values: []featureflags.Value{
+		{Name: "http_timeout", Value: 30},
+	},
+}
+
+// checkout is code under test, which depends on the Client interface
+func checkout(flags featureflags.Client) string {
+	if flags.Get("new_checkout") {
+		return "new"
+	}
+	return "old"
+}
+
+// Test the fake client starts with defaults and receives changes immediately
+func TestFakeClient(t *testing.T) {
+	flags := NewFakeClient(t, defaults)
+
+	if result := checkout(flags); result != "old" {
+		t.Errorf("Expected old checkout by default, got %s", result)
+	}
+	if val := flags.MustGetValueInt("http_timeout"); val != 30 {
+		t.Errorf("Expected default http_timeout 30, got %d", val)
+	}
+
+	flags.SetFlag("new_checkout", true)
+	flags.SetValue("http_timeout", 50)
+	if result := checkout(flags); result != "new" {
+		t.Errorf("Expected new checkout after SetFlag, got %s", result)
+	}
+	if val := flags.MustGetValueInt("http_timeout"); val != 50 {
+		t.Errorf("Expected http_timeout 50 after SetValue, got %d", val)
+	}
+}
+
+// Test forced outcomes apply for the duration of a test and are cleared afterwards
+func TestForce(t *testing.T) {
+	flags := NewFakeClient(t, defaults)
+	flags.SetFlag("new_checkout", true)
+
+	for _, test := range []struct {
+		enabled  bool
+		expected string
+	}{
+		{enabled: true, expected: "new"},
+		{enabled: false, expected: "old"},
+	} {
+		t.Run(test.expected, func(t *testing.T) {
+			Force(t, flags, "new_checkout", test.enabled)
+			if result := checkout(flags); result != test.expected {
+				t.Errorf("Expected %s checkout, got %s", test.expected, result)
+			}
+		})
+		if result := checkout(flags); result != "new" {
+			t.Errorf("Expected the server state after the test, got %s", result)
+		}
+	}
+}
+
+// Test nested forces restore the outer override when the inner test finishes
+func TestForceNested(t *testing.T) {
+	flags := NewFakeClient(t, defaults)
+	flags.Override("new_checkout", true)
+
+	t.Run("outer", func(t *testing.T) {
+		Force(t, flags, "new_checkout", false)
+		t.Run("inner", func(t *testing.T) {
+			Force(t, flags, "new_checkout", true)
+			if result := checkout(flags); result != "new" {
+				t.Errorf("Expected new checkout in the inner test, got %s", result)
+			}
+		})
+		if result := checkout(flags); result != "old" {
+			t.Errorf("Expected the outer override after the inner test, got %s", result)
+		}
+	})
+	if enabled, ok := flags.LocalOverride("new_checkout"); !ok || !enabled {
+		t.Error("Expected the override set before the tests to be restored")
+	}
+}
+
+// parallelTB is a test which is parallel, so t.Setenv panics in it
+type parallelTB struct {
+	testing.TB
+	failed string
+}
+
+func (t *parallelTB) Setenv(key, value string) {
+	panic("testing: t.Setenv called after t.Parallel")
+}
+
+func (t *parallelTB) Fatalf(format string, args ...any) {
+	t.failed = fmt.Sprintf(format, args...)
+}
+
+// Test Force fails parallel tests instead of overriding the shared client
+func TestForceParallel(t *testing.T) {
+	flags := NewFakeClient(t, defaults)
+
+	parallel := &parallelTB{TB: t}
+	Force(parallel, flags, "new_checkout", true)
+	if parallel.failed == "" {
+		t.Error("Expected Force to fail a parallel test")
+	}
+	if _, ok := flags.LocalOverride("new_checkout"); ok {
+		t.Error("Expected Force not to override the client in a parallel test")
+	}
+}
+
+// Test parallel tests sharing a client can force flags in their contexts
+func TestForceContext(t *testing.T) {
+	flags := NewFakeClient(t, defaults)
+
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprint(enabled), func(t *testing.T) {
+			t.Parallel()
+			ctx := flags.ForceContext(context.Background(), "new_checkout", enabled)
+			if got := flags.GetContext(ctx, "new_checkout"); got != enabled {
+				t.Errorf("Expected new_checkout %v, got %v", enabled, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package fftest provides a fake feature flags server and client for tests of code
+// which depends on flags, without a real server.
+package fftest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/evo-company/featureflags-go/proto"
+)
+
+// Server is an in-memory feature flags server. Every change bumps the version,
+// so clients receive it on their next sync.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	version int
+	flags   map[string]bool
+	values  map[string]any
+}
+
+// NewServer starts a fake server, which is closed when the test finishes
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		version: 1,
+		flags:   make(map[string]bool),
+		values:  make(map[string]any),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /flags/load", s.handle)
+	mux.HandleFunc("POST /flags/sync", s.handle)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// SetFlag sets the flag state on the server
+func (s *Server) SetFlag(name string, enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags[name] = enabled
+	s.version++
+}
+
+// SetValue sets the value on the server. It is sent as JSON, so numbers
+// are received by clients as float64, like from a real server.
+func (s *Server) SetValue(name string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[name] = value
+	s.version++
+}
+
+// Version returns the current version of the server state
+func (s *Server) Version() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version
+}
+
+// handle replies to load and sync requests with the whole state,
+// or with no changes if the client already has the current version
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Version int `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	res := proto.LoadFlagsResponse{Version: s.version}
+	if req.Version != s.version {
+		for name, enabled := range s.flags {
+			res.Flags = append(res.Flags, proto.FlagResponse{Name: name, Enabled: enabled})
+		}
+		for name, value := range s.values {
+			res.Values = append(res.Values, proto.ValueResponse{Name: name, Value: value})
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(res.Flags, func(i, j int) bool { return res.Flags[i].Name < res.Flags[j].Name })
+	sort.Slice(res.Values, func(i, j int) bool { return res.Values[i].Name < res.Values[j].Name })
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
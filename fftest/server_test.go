@@ -0,0 +1,49 @@
+package fftest
+
+import (
+	"context"
+	"testing"
+
+	featureflags "github.com/evo-company/featureflags-go"
+)
+
+// Test a real client syncs changes from the fake server
+func TestServer(t *testing.T) {
+	server := NewServer(t)
+	server.SetFlag("new_checkout", true)
+
+	client, err := featureflags.MakeClient(context.Background(), server.URL, "test-project", defaults)
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if !client.Get("new_checkout") {
+		t.Error("Expected new_checkout from the server to be enabled")
+	}
+
+	version := server.Version()
+	server.SetValue("http_timeout", 50)
+	if server.Version() != version+1 {
+		t.Errorf("Expected version %d, got %d", version+1, server.Version())
+	}
+
+	res, err := client.SyncRequest()
+	if err != nil {
+		t.Fatalf("SyncRequest failed: %v", err)
+	}
+	if res.Version != version+1 || len(res.Flags) != 1 || len(res.Values) != 1 {
+		t.Errorf("Expected the whole state at version %d, got %+v", version+1, res)
+	}
+
+	if err := client.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	res, err = client.SyncRequest()
+	if err != nil {
+		t.Fatalf("SyncRequest failed: %v", err)
+	}
+	if len(res.Flags) != 0 || len(res.Values) != 0 {
+		t.Errorf("Expected no changes for the current version, got %+v", res)
+	}
+}
@@ -1,5 +1,7 @@
 package featureflags
 
+import "time"
+
 type Conditions struct{}
 
 func LessThan(left, right string) bool {
@@ -11,8 +13,58 @@ func Equal(left string, right string) bool {
 }
 
 type FlagState struct {
-	Name    string
-	Enabled bool
+	Name           string
+	Enabled        bool
+	DefaultEnabled bool      // state declared in defaults
+	ExpiresAt      time.Time // set for temporary server overrides, zero if permanent
+	StartsAt       time.Time // start of the activation window, zero if open
+	EndsAt         time.Time // end of the activation window, zero if open
+	Payload        []string  // payload carried by the flag when enabled
+	DefaultPayload []string  // payload declared in defaults
+
+	// local overrides set by Override take precedence over the server state
+	overridden bool
+	override   bool
+	// fromServer is set once the server has sent the flag, it is declared in defaults before
+	fromServer bool
+}
+
+// current returns the flag state, reverting to the default after a temporary override
+// expires and outside of the activation window
+func (flag FlagState) current(clock Clock) bool {
+	if flag.overridden {
+		return flag.override
+	}
+	if !flag.active(clock) {
+		return flag.DefaultEnabled
+	}
+	return flag.Enabled
+}
+
+// active reports whether the server state applies: it is not an expired temporary
+// override, and the time is within its activation window. The clock is only read
+// for flags which have an expiration or a window.
+func (flag FlagState) active(clock Clock) bool {
+	if flag.ExpiresAt.IsZero() && flag.StartsAt.IsZero() && flag.EndsAt.IsZero() {
+		return true
+	}
+	t := now(clock)
+	return (flag.ExpiresAt.IsZero() || t.Before(flag.ExpiresAt)) &&
+		(flag.StartsAt.IsZero() || !t.Before(flag.StartsAt)) &&
+		(flag.EndsAt.IsZero() || t.Before(flag.EndsAt))
+}
+
+// expired reports whether a temporary override has expired
+func expired(clock Clock, expiresAt time.Time) bool {
+	return !expiresAt.IsZero() && !now(clock).Before(expiresAt)
+}
+
+// currentPayload returns the flag payload, reverting to the default like current
+func (flag FlagState) currentPayload(clock Clock) []string {
+	if !flag.active(clock) || flag.Payload == nil {
+		return flag.DefaultPayload
+	}
+	return flag.Payload
 }
 
 func (state *State) FlagState(name string) bool {
@@ -20,23 +72,72 @@ func (state *State) FlagState(name string) bool {
 	value, foundValue := state.flagState[name]
 
 	if foundValue {
-		result = value.Enabled
+		result = value.current(state.clock)
 	}
 	return result
 }
 
-func (flags *FeatureFlags) Get(name string) bool {
-	flags.mu.RLock()
-	defer flags.mu.RUnlock()
-	return flags.state.FlagState(name)
+func (flags *FeatureFlags) Get(name string) (enabled bool) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationFlag, name, enabled, nil, start) }()
+	}
+
+	return flags.getFlag(flags.loadState(), name)
 }
 
-type FlagResponse struct {
-	Name    string `json:"name"`
-	Enabled bool   `json:"enabled"`
+// getFlag returns the current state of the flag in the given state
+func (flags *FeatureFlags) getFlag(state *State, name string) bool {
+	flag, exists := state.flagState[flags.resolveAlias(state, name)]
+	if !exists {
+		flags.unknownFlag(name)
+		return false
+	}
+	return flag.current(state.clock)
+}
+
+// EvaluateAll returns states of all known flags from a single version of the state,
+// e.g. to hand a complete flag set to a frontend client.
+func (flags *FeatureFlags) EvaluateAll() map[string]bool {
+	state := flags.loadState()
+
+	result := make(map[string]bool, len(state.flagState))
+	for name, flag := range state.flagState {
+		result[name] = flag.current(state.clock)
+	}
+	return result
+}
+
+// GetFlagPayload returns the payload of an enabled flag, e.g. a list of enabled sub-features.
+// It returns false if the flag is disabled or doesn't exist. If the server sends
+// no payload for the flag, the payload declared in defaults is used.
+func (flags *FeatureFlags) GetFlagPayload(name string) (payload []string, enabled bool) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationFlag, name, enabled, nil, start) }()
+	}
+
+	state := flags.loadState()
+
+	flag, exists := state.flagState[flags.resolveAlias(state, name)]
+	if !exists {
+		flags.unknownFlag(name)
+		return nil, false
+	}
+	if !flag.current(state.clock) {
+		return nil, false
+	}
+	// Copy the payload, so callers can't modify the state
+	return append([]string(nil), flag.currentPayload(state.clock)...), true
 }
 
 type Flag struct {
 	Name    string `json:"name"`
 	Enabled bool   `json:"enabled"`
+	// Payload is returned by GetFlagPayload while the flag is enabled,
+	// unless the server sends its own payload
+	Payload []string `json:"payload,omitempty"`
+	// Aliases are former names of the flag: Get with an alias returns this flag
+	// during a rename, see AliasUsage
+	Aliases []string `json:"aliases,omitempty"`
 }
@@ -1,17 +1,19 @@
 package featureflags
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 // Test Get method for flags
 func TestGet(t *testing.T) {
-	flags := &FeatureFlags{
-		state: State{
-			flagState: map[string]FlagState{
-				"enabled_flag":  {Name: "enabled_flag", Enabled: true},
-				"disabled_flag": {Name: "disabled_flag", Enabled: false},
-			},
+	flags := &FeatureFlags{}
+	flags.state.Store(&State{
+		flagState: map[string]FlagState{
+			"enabled_flag":  {Name: "enabled_flag", Enabled: true},
+			"disabled_flag": {Name: "disabled_flag", Enabled: false},
 		},
-	}
+	})
 
 	t.Run("get enabled flag", func(t *testing.T) {
 		if !flags.Get("enabled_flag") {
@@ -31,3 +33,206 @@ func TestGet(t *testing.T) {
 		}
 	})
 }
+
+// Test temporary flag overrides revert to defaults after expiry
+func TestGetExpiredOverride(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Minute)
+
+	state := State{
+		flagState: map[string]FlagState{
+			"expired_flag": {Name: "expired_flag", Enabled: false, DefaultEnabled: false},
+			"active_flag":  {Name: "active_flag", Enabled: false, DefaultEnabled: false},
+		},
+	}
+	state.Update(1, []FlagResponse{
+		{Name: "expired_flag", Enabled: true, ExpiresAt: &past},
+		{Name: "active_flag", Enabled: true, ExpiresAt: &future},
+	}, nil)
+	flags := &FeatureFlags{}
+	flags.state.Store(&state)
+
+	if flags.Get("expired_flag") {
+		t.Error("Expected expired_flag to revert to default")
+	}
+	if !flags.Get("active_flag") {
+		t.Error("Expected active_flag to use the override before expiry")
+	}
+}
+
+// Test GetFlagPayload returns payload of enabled flags
+func TestGetFlagPayload(t *testing.T) {
+	state := State{
+		flagState: map[string]FlagState{
+			"server_payload":  {Name: "server_payload", DefaultPayload: []string{"default"}},
+			"default_payload": {Name: "default_payload", DefaultPayload: []string{"default"}},
+			"disabled_flag":   {Name: "disabled_flag", DefaultPayload: []string{"default"}},
+		},
+	}
+	state.Update(1, []FlagResponse{
+		{Name: "server_payload", Enabled: true, Payload: []string{"a", "b"}},
+		{Name: "default_payload", Enabled: true},
+		{Name: "disabled_flag", Enabled: false, Payload: []string{"a"}},
+	}, nil)
+	flags := &FeatureFlags{}
+	flags.state.Store(&state)
+
+	t.Run("payload from server", func(t *testing.T) {
+		payload, enabled := flags.GetFlagPayload("server_payload")
+		if !enabled || len(payload) != 2 || payload[0] != "a" {
+			t.Errorf("Expected [a b], got %v (enabled: %v)", payload, enabled)
+		}
+
+		// Returned payload is a copy
+		payload[0] = "modified"
+		if payload, _ := flags.GetFlagPayload("server_payload"); payload[0] != "a" {
+			t.Error("Expected state payload not to be modified")
+		}
+	})
+
+	t.Run("payload from defaults", func(t *testing.T) {
+		payload, enabled := flags.GetFlagPayload("default_payload")
+		if !enabled || len(payload) != 1 || payload[0] != "default" {
+			t.Errorf("Expected [default], got %v (enabled: %v)", payload, enabled)
+		}
+	})
+
+	t.Run("disabled flag", func(t *testing.T) {
+		if payload, enabled := flags.GetFlagPayload("disabled_flag"); enabled || payload != nil {
+			t.Errorf("Expected no payload for disabled flag, got %v", payload)
+		}
+	})
+
+	t.Run("non-existent flag", func(t *testing.T) {
+		if _, enabled := flags.GetFlagPayload("non_existent"); enabled {
+			t.Error("Expected non_existent flag to be disabled")
+		}
+	})
+}
+
+// Test EvaluateAll returns all flags
+func TestEvaluateAll(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	flags := &FeatureFlags{}
+	flags.state.Store(&State{
+		flagState: map[string]FlagState{
+			"enabled_flag":  {Name: "enabled_flag", Enabled: true},
+			"disabled_flag": {Name: "disabled_flag", Enabled: false},
+			"expired_flag":  {Name: "expired_flag", Enabled: true, DefaultEnabled: false, ExpiresAt: past},
+		},
+	})
+
+	result := flags.EvaluateAll()
+	expected := map[string]bool{"enabled_flag": true, "disabled_flag": false, "expired_flag": false}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, result)
+	}
+	for name, enabled := range expected {
+		if result[name] != enabled {
+			t.Errorf("Expected %s to be %v, got %v", name, enabled, result[name])
+		}
+	}
+}
+
+func newBenchmarkFlags() *FeatureFlags {
+	flags := &FeatureFlags{
+		logger: &defaultLogger{},
+	}
+	flags.state.Store(&State{
+		flagState: map[string]FlagState{
+			"some_flag": {Name: "some_flag", Enabled: true},
+		},
+		flagNames: []string{"some_flag"},
+	})
+	return flags
+}
+
+// newEvaluationFlags returns flags covering the shapes of flag lookups:
+// plain, temporary server override, local override, alias and unknown flag
+func newEvaluationFlags() *FeatureFlags {
+	flags := &FeatureFlags{logger: &defaultLogger{}}
+	flags.state.Store(&State{
+		flagState: map[string]FlagState{
+			"some_flag":       {Name: "some_flag", Enabled: true},
+			"expiring_flag":   {Name: "expiring_flag", Enabled: true, ExpiresAt: time.Now().Add(time.Hour)},
+			"overridden_flag": {Name: "overridden_flag", overridden: true, override: true},
+		},
+		flagNames: []string{"some_flag", "expiring_flag", "overridden_flag"},
+		aliases:   map[string]string{"old_flag": "some_flag"},
+	})
+	return flags
+}
+
+var flagLookups = []struct {
+	name string
+	flag string
+}{
+	{"plain", "some_flag"},
+	{"expiring", "expiring_flag"},
+	{"overridden", "overridden_flag"},
+	{"alias", "old_flag"},
+	{"unknown", "unknown_flag"},
+}
+
+// Benchmark flag lookups of every shape
+func BenchmarkGet(b *testing.B) {
+	flags := newEvaluationFlags()
+	for _, lookup := range flagLookups {
+		b.Run(lookup.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				flags.Get(lookup.flag)
+			}
+		})
+	}
+}
+
+// Test flag lookups don't allocate, unless the error policy logs unknown flags
+func TestGetAllocs(t *testing.T) {
+	flags := newEvaluationFlags()
+	for _, lookup := range flagLookups {
+		if allocs := testing.AllocsPerRun(100, func() { flags.Get(lookup.flag) }); allocs != 0 {
+			t.Errorf("Expected no allocations for %s lookup, got %v", lookup.name, allocs)
+		}
+	}
+}
+
+// Benchmark concurrent flag reads
+func BenchmarkGetParallel(b *testing.B) {
+	flags := newBenchmarkFlags()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			flags.Get("some_flag")
+		}
+	})
+}
+
+// Benchmark concurrent flag reads while the state is being updated
+func BenchmarkGetParallelWithUpdates(b *testing.B) {
+	flags := newBenchmarkFlags()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for version := 1; ; version++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			flags.update(version, []FlagResponse{{Name: "some_flag", Enabled: version%2 == 0}}, nil)
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			flags.Get("some_flag")
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	<-done
+}
@@ -0,0 +1,72 @@
+package featureflags
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// FlagHandle is a reference to a single flag, created once with FeatureFlags.Flag
+// and reused in hot paths. It caches the flag lookup until the state changes,
+// so repeated checks don't hash the flag name.
+//
+//	var newCheckout = flags.Flag("new_checkout")
+//
+//	if newCheckout.Enabled() {
+//		...
+//	}
+type FlagHandle struct {
+	flags  *FeatureFlags
+	name   string
+	lookup atomic.Pointer[flagLookup]
+}
+
+// flagLookup is the result of a flag lookup in a particular state
+type flagLookup struct {
+	state  *State
+	flag   FlagState
+	exists bool
+	// alias is the new name of the flag, if the handle uses an alias
+	alias string
+}
+
+// Flag returns a handle for the flag. Handles are safe for concurrent use,
+// and stay valid across syncs.
+func (flags *FeatureFlags) Flag(name string) *FlagHandle {
+	return &FlagHandle{flags: flags, name: name}
+}
+
+// Name returns the name of the flag
+func (handle *FlagHandle) Name() string {
+	return handle.name
+}
+
+// Enabled returns the current state of the flag, it behaves like FeatureFlags.Get
+func (handle *FlagHandle) Enabled() (enabled bool) {
+	flags := handle.flags
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationFlag, handle.name, enabled, nil, start) }()
+	}
+
+	state := flags.loadState()
+	lookup := handle.lookup.Load()
+	if lookup == nil || lookup.state != state {
+		name := handle.name
+		alias, aliased := state.aliases[name]
+		if aliased {
+			name = alias
+		}
+		flag, exists := state.flagState[name]
+		lookup = &flagLookup{state: state, flag: flag, exists: exists, alias: alias}
+		handle.lookup.Store(lookup)
+	}
+	if lookup.alias != "" {
+		flags.aliasUsed(handle.name, lookup.alias)
+	}
+
+	if !lookup.exists {
+		flags.unknownFlag(handle.name)
+		return false
+	}
+	return lookup.flag.current(state.clock)
+}
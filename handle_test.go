@@ -0,0 +1,55 @@
+package featureflags
+
+import "testing"
+
+// Test flag handles follow state updates
+func TestFlagHandle(t *testing.T) {
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flags.state.Store(&State{
+		version: 1,
+		flagState: map[string]FlagState{
+			"some_flag": {Name: "some_flag", Enabled: false},
+		},
+		flagNames:  []string{"some_flag"},
+		valueState: map[string]ValueState{},
+	})
+
+	handle := flags.Flag("some_flag")
+	if handle.Name() != "some_flag" {
+		t.Errorf("Expected name some_flag, got %s", handle.Name())
+	}
+	if handle.Enabled() {
+		t.Error("Expected some_flag to be disabled")
+	}
+
+	flags.update(2, []FlagResponse{{Name: "some_flag", Enabled: true}}, nil)
+	if !handle.Enabled() {
+		t.Error("Expected some_flag to be enabled after update")
+	}
+
+	missing := flags.Flag("missing_flag")
+	if missing.Enabled() {
+		t.Error("Expected missing_flag to be disabled")
+	}
+
+	var evaluations []Evaluation
+	flags.hooks = []EvaluationHook{EvaluationHookFunc(func(evaluation Evaluation) {
+		evaluations = append(evaluations, evaluation)
+	})}
+	handle.Enabled()
+	if len(evaluations) != 1 || evaluations[0].Name != "some_flag" || evaluations[0].Result != true {
+		t.Errorf("Expected a single evaluation of some_flag, got %+v", evaluations)
+	}
+}
+
+// Benchmark flag checks through a handle
+func BenchmarkFlagHandleParallel(b *testing.B) {
+	flags := newBenchmarkFlags()
+	handle := flags.Flag("some_flag")
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			handle.Enabled()
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package featureflags
+
+import "time"
+
+// EvaluationKind tells whether a flag or a value was evaluated
+type EvaluationKind int
+
+const (
+	EvaluationFlag  EvaluationKind = iota + 1 // 1
+	EvaluationValue                           // 2
+)
+
+// Evaluation describes a single flag or value lookup
+type Evaluation struct {
+	Kind    EvaluationKind
+	Name    string
+	Result  any   // bool for flags, the returned value for values
+	Err     error // error returned by GetValue* getters, if any
+	Latency time.Duration
+}
+
+// EvaluationHook is invoked after every Get, GetValue and typed value getter call.
+// Hooks are called synchronously on the caller's goroutine, so they should be fast.
+type EvaluationHook interface {
+	AfterEvaluation(evaluation Evaluation)
+}
+
+// EvaluationHookFunc is an adapter to use ordinary functions as evaluation hooks
+type EvaluationHookFunc func(evaluation Evaluation)
+
+func (f EvaluationHookFunc) AfterEvaluation(evaluation Evaluation) {
+	f(evaluation)
+}
+
+// evaluated reports an evaluation which started at start to all hooks
+func (flags *FeatureFlags) evaluated(kind EvaluationKind, name string, result any, err error, start time.Time) {
+	evaluation := Evaluation{
+		Kind:    kind,
+		Name:    name,
+		Result:  result,
+		Err:     err,
+		Latency: time.Since(start),
+	}
+	for _, hook := range flags.hooks {
+		hook.AfterEvaluation(evaluation)
+	}
+}
@@ -0,0 +1,67 @@
+package featureflags
+
+import "testing"
+
+// Test evaluation hooks are invoked for flags and values
+func TestEvaluationHook(t *testing.T) {
+	var evaluations []Evaluation
+	flags := &FeatureFlags{
+		logger: &testLogger{},
+		hooks: []EvaluationHook{
+			EvaluationHookFunc(func(evaluation Evaluation) {
+				evaluations = append(evaluations, evaluation)
+			}),
+		},
+	}
+	flags.state.Store(&State{
+		flagState: map[string]FlagState{
+			"hooked_flag": {Name: "hooked_flag", Enabled: true},
+		},
+		valueState: map[string]ValueState{
+			"hooked_value": {Name: "hooked_value", Value: 42, DefaultValue: 10},
+		},
+	})
+
+	t.Run("flag evaluation", func(t *testing.T) {
+		evaluations = nil
+		flags.Get("hooked_flag")
+
+		if len(evaluations) != 1 {
+			t.Fatalf("Expected 1 evaluation, got %d", len(evaluations))
+		}
+		evaluation := evaluations[0]
+		if evaluation.Kind != EvaluationFlag || evaluation.Name != "hooked_flag" {
+			t.Errorf("Unexpected evaluation: %+v", evaluation)
+		}
+		if evaluation.Result != true {
+			t.Errorf("Expected result true, got %v", evaluation.Result)
+		}
+	})
+
+	t.Run("value evaluation", func(t *testing.T) {
+		evaluations = nil
+		flags.GetValue("hooked_value")
+		flags.MustGetValueInt("hooked_value")
+
+		if len(evaluations) != 2 {
+			t.Fatalf("Expected 2 evaluations, got %d", len(evaluations))
+		}
+		for _, evaluation := range evaluations {
+			if evaluation.Kind != EvaluationValue || evaluation.Result != 42 {
+				t.Errorf("Unexpected evaluation: %+v", evaluation)
+			}
+		}
+	})
+
+	t.Run("value evaluation error", func(t *testing.T) {
+		evaluations = nil
+		flags.GetValueString("hooked_value")
+
+		if len(evaluations) != 1 {
+			t.Fatalf("Expected 1 evaluation, got %d", len(evaluations))
+		}
+		if evaluations[0].Err == nil {
+			t.Error("Expected evaluation error for wrong type")
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// launchDarklyFlag is a flag in the full LaunchDarkly flag file format
+type launchDarklyFlag struct {
+	On           bool  `json:"on"`
+	Variations   []any `json:"variations"`
+	OffVariation *int  `json:"offVariation"`
+	Fallthrough  struct {
+		Variation *int `json:"variation"`
+	} `json:"fallthrough"`
+}
+
+// ImportLaunchDarkly converts a LaunchDarkly flag file into the state format read by
+// WithLocalSource. Both "flagValues" and "flags" sections are supported; for full flags
+// the variation served by the fallthrough (or the off variation) is used, as targeting
+// rules cannot be represented. Boolean flags become flags, other flags become values.
+func ImportLaunchDarkly(r io.Reader) (*LoadFlagsResponse, error) {
+	var file struct {
+		FlagValues map[string]any              `json:"flagValues"`
+		Flags      map[string]launchDarklyFlag `json:"flags"`
+	}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+
+	state := &LoadFlagsResponse{}
+	add := func(name string, value any) {
+		if enabled, ok := value.(bool); ok {
+			state.Flags = append(state.Flags, FlagResponse{Name: name, Enabled: enabled})
+		} else {
+			state.Values = append(state.Values, ValueResponse{Name: name, Value: value})
+		}
+	}
+
+	for name, flag := range file.Flags {
+		variation := flag.OffVariation
+		if flag.On {
+			variation = flag.Fallthrough.Variation
+		}
+		if variation == nil || *variation < 0 || *variation >= len(flag.Variations) {
+			return nil, fmt.Errorf("flag %s has no valid served variation", name)
+		}
+		add(name, flag.Variations[*variation])
+	}
+	for name, value := range file.FlagValues {
+		add(name, value)
+	}
+
+	sortState(state)
+	return state, nil
+}
+
+// ImportUnleash converts an Unleash feature toggles export into the state format read by
+// WithLocalSource. Features become flags, except features with a single variant carrying
+// a payload, which become values. Strategies cannot be represented and are ignored.
+func ImportUnleash(r io.Reader) (*LoadFlagsResponse, error) {
+	var export unleashExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+
+	state := &LoadFlagsResponse{}
+	for _, feature := range export.Features {
+		if len(feature.Variants) != 1 || feature.Variants[0].Payload.Type == "" {
+			state.Flags = append(state.Flags, FlagResponse{Name: feature.Name, Enabled: feature.Enabled})
+			continue
+		}
+
+		value, err := unleashPayloadValue(feature.Variants[0].Payload)
+		if err != nil {
+			return nil, fmt.Errorf("feature %s: %w", feature.Name, err)
+		}
+		state.Values = append(state.Values, ValueResponse{Name: feature.Name, Value: value})
+	}
+
+	sortState(state)
+	return state, nil
+}
+
+func unleashPayloadValue(payload unleashPayload) (any, error) {
+	switch payload.Type {
+	case "json":
+		var value any
+		err := json.Unmarshal([]byte(payload.Value), &value)
+		return value, err
+	case "number":
+		return strconv.ParseFloat(payload.Value, 64)
+	default:
+		return payload.Value, nil
+	}
+}
+
+func sortState(state *LoadFlagsResponse) {
+	sort.Slice(state.Flags, func(i, j int) bool { return state.Flags[i].Name < state.Flags[j].Name })
+	sort.Slice(state.Values, func(i, j int) bool { return state.Values[i].Name < state.Values[j].Name })
+}
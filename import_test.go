@@ -0,0 +1,91 @@
+package featureflags
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Test import from LaunchDarkly flag files
+func TestImportLaunchDarkly(t *testing.T) {
+	t.Run("flag values", func(t *testing.T) {
+		state, err := ImportLaunchDarkly(strings.NewReader(`{
+			"flagValues": {"some_flag": true, "some_value": "text"}
+		}`))
+		if err != nil {
+			t.Fatalf("ImportLaunchDarkly failed: %v", err)
+		}
+		if len(state.Flags) != 1 || state.Flags[0].Name != "some_flag" || !state.Flags[0].Enabled {
+			t.Errorf("Unexpected flags: %v", state.Flags)
+		}
+		if len(state.Values) != 1 || state.Values[0].Value != "text" {
+			t.Errorf("Unexpected values: %v", state.Values)
+		}
+	})
+
+	t.Run("full flags", func(t *testing.T) {
+		state, err := ImportLaunchDarkly(strings.NewReader(`{
+			"flags": {
+				"on_flag": {"on": true, "variations": [true, false], "fallthrough": {"variation": 0}, "offVariation": 1},
+				"off_flag": {"on": false, "variations": [true, false], "fallthrough": {"variation": 0}, "offVariation": 1},
+				"limit": {"on": true, "variations": [10, 20], "fallthrough": {"variation": 1}, "offVariation": 0}
+			}
+		}`))
+		if err != nil {
+			t.Fatalf("ImportLaunchDarkly failed: %v", err)
+		}
+		if len(state.Flags) != 2 || state.Flags[0].Enabled || !state.Flags[1].Enabled {
+			t.Errorf("Unexpected flags: %v", state.Flags)
+		}
+		if len(state.Values) != 1 || state.Values[0].Value != 20.0 {
+			t.Errorf("Unexpected values: %v", state.Values)
+		}
+	})
+
+	t.Run("invalid variation", func(t *testing.T) {
+		_, err := ImportLaunchDarkly(strings.NewReader(`{
+			"flags": {"bad": {"on": true, "variations": [true], "fallthrough": {"variation": 3}}}
+		}`))
+		if err == nil {
+			t.Error("Expected error for invalid variation")
+		}
+	})
+}
+
+// Test import from Unleash exports, including a round trip through ExportUnleash
+func TestImportUnleash(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newExportFlags().ExportUnleash(&buf); err != nil {
+		t.Fatalf("ExportUnleash failed: %v", err)
+	}
+
+	state, err := ImportUnleash(&buf)
+	if err != nil {
+		t.Fatalf("ImportUnleash failed: %v", err)
+	}
+	if len(state.Flags) != 2 {
+		t.Fatalf("Expected 2 flags, got %v", state.Flags)
+	}
+	if state.Flags[0].Name != "disabled_flag" || state.Flags[0].Enabled {
+		t.Errorf("Unexpected flag: %v", state.Flags[0])
+	}
+	if state.Flags[1].Name != "enabled_flag" || !state.Flags[1].Enabled {
+		t.Errorf("Unexpected flag: %v", state.Flags[1])
+	}
+	if len(state.Values) != 1 || state.Values[0].Name != "timeout" || state.Values[0].Value != 30.0 {
+		t.Errorf("Unexpected values: %v", state.Values)
+	}
+
+	t.Run("payload types", func(t *testing.T) {
+		state, err := ImportUnleash(strings.NewReader(`{"version": 1, "features": [
+			{"name": "number", "enabled": true, "variants": [{"name": "v", "payload": {"type": "number", "value": "1.5"}}]},
+			{"name": "string", "enabled": true, "variants": [{"name": "v", "payload": {"type": "string", "value": "text"}}]}
+		]}`))
+		if err != nil {
+			t.Fatalf("ImportUnleash failed: %v", err)
+		}
+		if state.Values[0].Value != 1.5 || state.Values[1].Value != "text" {
+			t.Errorf("Unexpected values: %v", state.Values)
+		}
+	})
+}
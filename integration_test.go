@@ -0,0 +1,95 @@
+//go:build integration
+
+package featureflags
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Integration tests run against a real feature flags server:
+//
+//	FEATUREFLAGS_URL=http://localhost:8080 go test -tags integration ./...
+func integrationServer(t *testing.T) string {
+	t.Helper()
+	url := os.Getenv("FEATUREFLAGS_URL")
+	if url == "" {
+		t.Skip("FEATUREFLAGS_URL is not set")
+	}
+	return url
+}
+
+// Test the client against a real server: Load creates the project, Sync keeps it up to date
+func TestIntegrationLoadAndSync(t *testing.T) {
+	url := integrationServer(t)
+	project := fmt.Sprintf("featureflags-go.integration.%d", time.Now().UnixNano())
+
+	defaults := Defaults{
+		Flags: []Flag{
+			{Name: "INTEGRATION_FLAG", Enabled: false},
+		},
+		Values: []Value{
+			{Name: "INTEGRATION_INT", Value: 10},
+			{Name: "INTEGRATION_STRING", Value: "default"},
+		},
+	}
+
+	client, err := MakeClient(
+		context.Background(),
+		url,
+		project,
+		defaults,
+		WithVariables([]Variable{{Name: "user.id", Type: TypeNumber}}),
+		WithRequestTimeout(5*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer client.Close()
+
+	// The version is assigned by the server, defaults alone are at version 0
+	version := client.Version()
+	if version == 0 {
+		t.Fatal("Expected Load to return a version of the server")
+	}
+
+	if err := client.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if client.Version() < version {
+		t.Errorf("Expected Sync not to go back from version %d, got %d", version, client.Version())
+	}
+
+	// A sync as of version 0 only returns names the server knows, so it tells whether
+	// Load has created the flag and the values on the server
+	var reply SyncFlagsResponse
+	err = client.post(context.Background(), "/flags/sync", SyncFlagsRequest{
+		Project: project,
+		Flags:   []string{"INTEGRATION_FLAG", "INTEGRATION_UNKNOWN"},
+		Values:  []string{"INTEGRATION_INT", "INTEGRATION_STRING"},
+	}, &reply)
+	if err != nil {
+		t.Fatalf("Sync request failed: %v", err)
+	}
+	if reply.Version < version {
+		t.Errorf("Expected the server to be at version %d or later, got %d", version, reply.Version)
+	}
+	var flagNames, valueNames []string
+	for _, flag := range reply.Flags {
+		flagNames = append(flagNames, flag.Name)
+	}
+	for _, value := range reply.Values {
+		valueNames = append(valueNames, value.Name)
+	}
+	if !slices.Equal(flagNames, []string{"INTEGRATION_FLAG"}) {
+		t.Errorf("Expected the server to know only the loaded flag, got %v", flagNames)
+	}
+	slices.Sort(valueNames)
+	if !slices.Equal(valueNames, []string{"INTEGRATION_INT", "INTEGRATION_STRING"}) {
+		t.Errorf("Expected the server to know the loaded values, got %v", valueNames)
+	}
+}
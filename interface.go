@@ -0,0 +1,35 @@
+package featureflags
+
+import "time"
+
+// Client is the evaluation API of FeatureFlags. Code which only reads flags and values
+// can depend on it, so tests can pass a client from the fftest package instead.
+type Client interface {
+	Get(name string) bool
+	GetFlagPayload(name string) ([]string, bool)
+	EvaluateAll() map[string]bool
+
+	GetValue(name string) interface{}
+	EvaluateAllValues() map[string]interface{}
+	IsValueOverridden(name string) bool
+	UnmarshalValue(name string, out any) error
+
+	GetValueInt(name string) (int, error)
+	MustGetValueInt(name string) int
+	GetValueString(name string) (string, error)
+	MustGetValueString(name string) string
+	GetValueBool(name string) (bool, error)
+	MustGetValueBool(name string) bool
+	GetValueFloat64(name string) (float64, error)
+	MustGetValueFloat64(name string) float64
+	GetValueDuration(name string) (time.Duration, error)
+	MustGetValueDuration(name string) time.Duration
+
+	GetValueIntOr(name string, fallback int) int
+	GetValueStringOr(name string, fallback string) string
+	GetValueBoolOr(name string, fallback bool) bool
+	GetValueFloat64Or(name string, fallback float64) float64
+	GetValueDurationOr(name string, fallback time.Duration) time.Duration
+}
+
+var _ Client = (*FeatureFlags)(nil)
@@ -0,0 +1,35 @@
+package featureflags
+
+import "time"
+
+// Version returns the version of the state received from the server, 0 until the
+// first successful Load.
+func (flags *FeatureFlags) Version() int {
+	return flags.loadState().version
+}
+
+// FlagNames returns names of the flags declared in defaults, which the client syncs.
+func (flags *FeatureFlags) FlagNames() []string {
+	return append([]string(nil), flags.loadState().flagNames...)
+}
+
+// ValueNames returns names of the values declared in defaults, which the client syncs.
+func (flags *FeatureFlags) ValueNames() []string {
+	return append([]string(nil), flags.loadState().valueNames...)
+}
+
+// LastSync returns the time of the last successful Load or Sync, including syncs
+// without changes. It is zero if the client has only used defaults or the state store.
+func (flags *FeatureFlags) LastSync() time.Time {
+	nanos := flags.lastSync.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// synced records the time of a successful Load or Sync
+func (flags *FeatureFlags) synced() {
+	flags.lastSync.Store(time.Now().UnixNano())
+	flags.rateLimitedUntil.Store(0)
+}
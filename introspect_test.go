@@ -0,0 +1,58 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test introspection getters report the state and the time of the last sync
+func TestIntrospection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(LoadFlagsResponse{Version: 7})
+	}))
+	defer server.Close()
+
+	flags := &FeatureFlags{
+		client:   server.Client(),
+		httpAddr: server.URL,
+		project:  "test-project",
+		logger:   &testLogger{},
+	}
+	flags.state.Store(&State{
+		flagState:  map[string]FlagState{"test_flag": {Name: "test_flag"}},
+		flagNames:  []string{"test_flag"},
+		valueState: map[string]ValueState{"test_value": {Name: "test_value"}},
+		valueNames: []string{"test_value"},
+	})
+
+	if flags.Version() != 0 || !flags.LastSync().IsZero() {
+		t.Errorf("Expected version 0 and no sync, got %d and %v", flags.Version(), flags.LastSync())
+	}
+
+	before := time.Now()
+	if err := flags.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if flags.Version() != 7 {
+		t.Errorf("Expected version 7, got %d", flags.Version())
+	}
+	if flags.LastSync().Before(before) {
+		t.Errorf("Expected last sync after %v, got %v", before, flags.LastSync())
+	}
+
+	names := flags.FlagNames()
+	if len(names) != 1 || names[0] != "test_flag" {
+		t.Errorf("Expected [test_flag], got %v", names)
+	}
+	// The returned slice is a copy
+	names[0] = "changed"
+	if flags.FlagNames()[0] != "test_flag" {
+		t.Error("Expected FlagNames to return a copy")
+	}
+	if values := flags.ValueNames(); len(values) != 1 || values[0] != "test_value" {
+		t.Errorf("Expected [test_value], got %v", values)
+	}
+}
@@ -0,0 +1,159 @@
+package featureflags
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// KV is a shared key-value store, e.g. Redis, which lets a fleet of instances share a
+// single connection to the feature flags server, see WithSharedCache.
+type KV interface {
+	// Get returns the value of the key, or an error if it doesn't exist
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores the value, a zero ttl means no expiration
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetNX stores the value only if the key doesn't exist and reports whether it was stored
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+}
+
+// KVNotifier is optionally implemented by a KV which can broadcast messages, e.g. with
+// Redis pub/sub. Instances are then notified as soon as the shared state is updated.
+type KVNotifier interface {
+	Publish(ctx context.Context, channel string) error
+	// Subscribe returns a channel receiving a message per publish, until ctx is done
+	Subscribe(ctx context.Context, channel string) (<-chan struct{}, error)
+}
+
+// errKVLeader makes the leader fall through to the server in the source chain
+var errKVLeader = errors.New("this instance syncs the shared cache with the server")
+
+// kvCache is a source and a state store backed by a KV. One instance, the leader, holds
+// a lease key and syncs with the server, saving every new state to the KV. The other
+// instances read the state from the KV and only fall back to the server if it fails.
+type kvCache struct {
+	kv       KV
+	id       string
+	leaseTTL time.Duration
+	// leading is the result of the last lease check, the leader ignores notifications
+	// of its own saves
+	leading atomic.Bool
+
+	// timeout bounds every call to the KV, so a hung KV can't block the sync loop. ctx is
+	// the client context for Save and Load, which the StateStore interface calls without one.
+	ctx     context.Context
+	timeout time.Duration
+}
+
+func newKVCache(ctx context.Context, kv KV, leaseTTL, timeout time.Duration) *kvCache {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return &kvCache{kv: kv, id: hex.EncodeToString(id), leaseTTL: leaseTTL, ctx: ctx, timeout: timeout}
+}
+
+// context returns a context for a StateStore call, cancelled after the timeout or when
+// the client is closed
+func (c *kvCache) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.ctx, c.timeout)
+}
+
+func kvStateKey(project string) string  { return "featureflags:" + project + ":state" }
+func kvLeaderKey(project string) string { return "featureflags:" + project + ":leader" }
+
+// lead acquires or extends the lease and reports whether this instance is the leader.
+// Extending is not atomic: if the lease expires between the check and the extension,
+// two instances sync with the server until the next lease check, which is harmless.
+func (c *kvCache) lead(ctx context.Context, project string) (bool, error) {
+	acquired, err := c.kv.SetNX(ctx, kvLeaderKey(project), []byte(c.id), c.leaseTTL)
+	if err != nil || acquired {
+		return acquired, err
+	}
+	owner, err := c.kv.Get(ctx, kvLeaderKey(project))
+	if err != nil {
+		return false, err
+	}
+	if string(owner) != c.id {
+		return false, nil
+	}
+	return true, c.kv.Set(ctx, kvLeaderKey(project), []byte(c.id), c.leaseTTL)
+}
+
+func (c *kvCache) Fetch(ctx context.Context, project string) (*LoadFlagsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	leader, err := c.lead(ctx, project)
+	c.leading.Store(leader)
+	if err != nil {
+		return nil, err
+	}
+	if leader {
+		return nil, errKVLeader
+	}
+	return c.load(ctx, project)
+}
+
+func (c *kvCache) load(ctx context.Context, project string) (*LoadFlagsResponse, error) {
+	data, err := c.kv.Get(ctx, kvStateKey(project))
+	if err != nil {
+		return nil, err
+	}
+	var state LoadFlagsResponse
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Save stores a state received from the server and notifies other instances. Only the
+// leader saves, a follower which fell back to the server could overwrite a newer state.
+func (c *kvCache) Save(project string, state *LoadFlagsResponse) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	owner, err := c.kv.Get(ctx, kvLeaderKey(project))
+	if err != nil || string(owner) != c.id {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := c.kv.Set(ctx, kvStateKey(project), data, 0); err != nil {
+		return err
+	}
+	if notifier, ok := c.kv.(KVNotifier); ok {
+		return notifier.Publish(ctx, kvStateKey(project))
+	}
+	return nil
+}
+
+// Load returns the shared state, it is used when the server is unreachable on startup
+func (c *kvCache) Load(project string) (*LoadFlagsResponse, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.load(ctx, project)
+}
+
+// subscribe triggers a sync on every update of the shared state, until the client is closed.
+// The leader skips notifications: it has saved the updates itself.
+func (flags *FeatureFlags) subscribe(cache *kvCache) error {
+	notifier, ok := cache.kv.(KVNotifier)
+	if !ok {
+		return nil
+	}
+	updates, err := notifier.Subscribe(flags.context(), kvStateKey(flags.project))
+	if err != nil {
+		return err
+	}
+	go func() {
+		for range updates {
+			if !cache.leading.Load() {
+				flags.TriggerSync()
+			}
+		}
+	}()
+	return nil
+}
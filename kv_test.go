@@ -0,0 +1,245 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryKV is a KV with pub/sub, ttls are ignored
+type memoryKV struct {
+	mu          sync.Mutex
+	data        map[string][]byte
+	subscribers map[string][]chan struct{}
+}
+
+func newMemoryKV() *memoryKV {
+	return &memoryKV{data: make(map[string][]byte), subscribers: make(map[string][]chan struct{})}
+}
+
+func (kv *memoryKV) Get(ctx context.Context, key string) ([]byte, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	value, ok := kv.data[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return value, nil
+}
+
+func (kv *memoryKV) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.data[key] = value
+	return nil
+}
+
+func (kv *memoryKV) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	if _, ok := kv.data[key]; ok {
+		return false, nil
+	}
+	kv.data[key] = value
+	return true, nil
+}
+
+func (kv *memoryKV) Publish(ctx context.Context, channel string) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	for _, ch := range kv.subscribers[channel] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (kv *memoryKV) Subscribe(ctx context.Context, channel string) (<-chan struct{}, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	kv.subscribers[channel] = append(kv.subscribers[channel], ch)
+	return ch, nil
+}
+
+// Test a single instance syncs with the server and others read the shared state
+func TestSharedCache(t *testing.T) {
+	var requests atomic.Int32
+	var enabled atomic.Bool
+	var version atomic.Int64
+	version.Store(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		json.NewEncoder(w).Encode(LoadFlagsResponse{
+			Version: int(version.Load()),
+			Flags:   []FlagResponse{{Name: "test_flag", Enabled: enabled.Load()}},
+		})
+	}))
+	defer server.Close()
+
+	kv := newMemoryKV()
+	newClient := func() *FeatureFlags {
+		flags, err := MakeClient(context.Background(), server.URL, "test-project",
+			Defaults{Flags: []Flag{{Name: "test_flag"}}},
+			WithSharedCache(kv), WithLogger(&testLogger{}), WithSyncInterval(time.Hour))
+		if err != nil {
+			t.Fatalf("MakeClient failed: %v", err)
+		}
+		t.Cleanup(func() { flags.Close() })
+		return flags
+	}
+
+	leader := newClient()
+	follower := newClient()
+	if requests.Load() != 1 {
+		t.Errorf("Expected only the leader to load from the server, got %d requests", requests.Load())
+	}
+	if follower.Version() != 1 {
+		t.Errorf("Expected the follower to read version 1 from the cache, got %d", follower.Version())
+	}
+
+	enabled.Store(true)
+	version.Store(2)
+	if err := leader.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// The update is published, so the follower syncs from the cache right away
+	deadline := time.Now().Add(time.Second)
+	for !follower.Get("test_flag") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !follower.Get("test_flag") {
+		t.Error("Expected the follower to pick up the shared state")
+	}
+}
+
+// Test a follower falls back to the server when the shared state is missing
+func TestSharedCacheFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(LoadFlagsResponse{
+			Version: 1,
+			Flags:   []FlagResponse{{Name: "test_flag", Enabled: true}},
+		})
+	}))
+	defer server.Close()
+
+	kv := newMemoryKV()
+	kv.Set(context.Background(), kvLeaderKey("test-project"), []byte("other-instance"), 0)
+
+	flags, err := MakeClient(context.Background(), server.URL, "test-project",
+		Defaults{Flags: []Flag{{Name: "test_flag"}}},
+		WithSharedCache(kv), WithLogger(&testLogger{}), WithSyncInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	if !flags.Get("test_flag") {
+		t.Error("Expected the state from the server")
+	}
+	// Only the leader saves to the cache
+	if _, err := kv.Get(context.Background(), kvStateKey("test-project")); err == nil {
+		t.Error("Expected the follower not to save the state")
+	}
+}
+
+// hangingKV is a KV whose writes block until the context is done
+type hangingKV struct {
+	*memoryKV
+}
+
+func (kv hangingKV) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Test saves to a hung KV are bounded by the timeout instead of blocking the sync loop
+func TestSharedCacheTimeout(t *testing.T) {
+	kv := hangingKV{newMemoryKV()}
+	cache := newKVCache(context.Background(), kv, time.Minute, 20*time.Millisecond)
+	if leader, err := cache.lead(context.Background(), "test-project"); !leader || err != nil {
+		t.Fatalf("Expected the cache to lead, got %v, %v", leader, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cache.Save("test-project", &LoadFlagsResponse{Version: 1}) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the save to time out, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Save not to block on a hung KV")
+	}
+}
+
+// stuckKV is a KV whose calls block until the context is done
+type stuckKV struct{}
+
+func (stuckKV) Get(ctx context.Context, key string) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (stuckKV) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (stuckKV) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+// Test fetches from a hung KV are bounded by the timeout even with an unbounded context
+func TestSharedCacheFetchTimeout(t *testing.T) {
+	cache := newKVCache(context.Background(), stuckKV{}, time.Minute, 20*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.Fetch(context.Background(), "test-project")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the fetch to time out, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Fetch not to block on a hung KV")
+	}
+}
+
+// Test the leader doesn't sync again on notifications of its own saves
+func TestSharedCacheSelfNotification(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		json.NewEncoder(w).Encode(LoadFlagsResponse{Version: int(requests.Load())})
+	}))
+	defer server.Close()
+
+	flags, err := MakeClient(context.Background(), server.URL, "test-project", Defaults{},
+		WithSharedCache(newMemoryKV()), WithLogger(&testLogger{}), WithSyncInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if requests.Load() != 2 {
+		t.Errorf("Expected only the load and the sync to reach the server, got %d requests", requests.Load())
+	}
+}
@@ -0,0 +1,26 @@
+package featureflags
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+const (
+	labelProject   = "featureflags.project"
+	labelOperation = "featureflags.operation"
+)
+
+// labels returns pprof labels attributing CPU time to the client and the given operation
+func (flags *FeatureFlags) labels(operation string) pprof.LabelSet {
+	return pprof.Labels(labelProject, flags.project, labelOperation, operation)
+}
+
+// withLabels runs f with pprof labels of the operation added to ctx and the current goroutine,
+// so profiles attribute the cost of syncing and loading to the feature flags client.
+// Labels of ctx are restored when f returns.
+//
+// Getters are not labeled: they don't take a context, and labeling them would
+// discard labels the caller has set on its goroutine.
+func (flags *FeatureFlags) withLabels(ctx context.Context, operation string, f func(ctx context.Context)) {
+	pprof.Do(ctx, flags.labels(operation), f)
+}
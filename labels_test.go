@@ -0,0 +1,58 @@
+package featureflags
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Test requests made by Load and Sync carry pprof labels of the operation
+func TestLabels(t *testing.T) {
+	var operations []string
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if project, _ := pprof.Label(req.Context(), labelProject); project != "test-project" {
+			t.Errorf("Expected project label test-project, got %q", project)
+		}
+		if app, _ := pprof.Label(req.Context(), "app"); app != "test" {
+			t.Errorf("Expected caller label app to be kept, got %q", app)
+		}
+		operation, _ := pprof.Label(req.Context(), labelOperation)
+		operations = append(operations, operation)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"version": 1}`)),
+		}, nil
+	})
+
+	flags := &FeatureFlags{
+		client:   &http.Client{Transport: transport},
+		project:  "test-project",
+		logger:   &testLogger{},
+		httpAddr: "http://featureflags",
+	}
+
+	// Caller labels are kept and restored after the call
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("app", "test"))
+	if err := flags.LoadContext(ctx); err != nil {
+		t.Fatalf("LoadContext failed: %v", err)
+	}
+	if err := flags.SyncContext(ctx); err != nil {
+		t.Fatalf("SyncContext failed: %v", err)
+	}
+
+	if len(operations) != 2 || operations[0] != "load" || operations[1] != "sync" {
+		t.Errorf("Expected load and sync operations, got %v", operations)
+	}
+	if _, ok := pprof.Label(ctx, labelOperation); ok {
+		t.Error("Expected caller context not to be modified")
+	}
+}
@@ -0,0 +1,98 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// localSource reads project state from a JSON file instead of the feature flags server.
+//
+// The file uses the same schema as the /flags/load response:
+//
+//	{
+//	    "version": 1,
+//	    "flags": [{"name": "some_flag", "enabled": true}],
+//	    "values": [{"name": "some_value", "value": 42}]
+//	}
+//
+// The "version" field is optional. When it is omitted, the version is bumped
+// every time the file changes, so edits are picked up on the next sync.
+//
+// Only JSON is supported, YAML files are rejected with ErrorUnsupportedFormat. Changes are
+// detected by polling: the modification time and the symlink target are checked on every
+// sync, there is no filesystem notification watch.
+type localSource struct {
+	path string
+
+	mu      sync.Mutex
+	target  string // path with symlinks resolved, a ConfigMap update swaps them
+	modTime time.Time
+	version int
+	last    *LoadFlagsResponse
+}
+
+// stat returns the resolved path and the modification time of the file
+func (src *localSource) stat() (string, time.Time, error) {
+	target, err := filepath.EvalSymlinks(src.path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return target, info.ModTime(), nil
+}
+
+// ErrorUnsupportedFormat is returned by local sources for files in formats other than JSON
+var ErrorUnsupportedFormat = errors.New("unsupported local source format, only JSON is supported")
+
+// read returns the current file contents. The file is only decoded again
+// when its modification time or its symlink target changes.
+func (src *localSource) read() (*LoadFlagsResponse, error) {
+	switch strings.ToLower(filepath.Ext(src.path)) {
+	case ".yaml", ".yml":
+		return nil, fmt.Errorf("%w: %s", ErrorUnsupportedFormat, src.path)
+	}
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	target, modTime, err := src.stat()
+	if err != nil {
+		return nil, err
+	}
+	if src.last != nil && target == src.target && modTime.Equal(src.modTime) {
+		return src.last, nil
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply LoadFlagsResponse
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return nil, err
+	}
+	if reply.Version == 0 {
+		src.version++
+		reply.Version = src.version
+	}
+
+	src.target = target
+	src.modTime = modTime
+	src.last = &reply
+	return &reply, nil
+}
+
+func (src *localSource) Fetch(ctx context.Context, project string) (*LoadFlagsResponse, error) {
+	return src.read()
+}
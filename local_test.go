@@ -0,0 +1,92 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test WithLocalSource reads state from a file and picks up changes on Sync
+func TestLocalSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	writeFile := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set file times: %v", err)
+		}
+	}
+
+	now := time.Now()
+	writeFile(`{
+		"flags": [{"name": "local_flag", "enabled": true}],
+		"values": [{"name": "local_value", "value": "from_file"}]
+	}`, now)
+
+	defaults := Defaults{
+		Flags:  []Flag{{Name: "local_flag", Enabled: false}},
+		Values: []Value{{Name: "local_value", Value: "default"}},
+	}
+
+	client, err := MakeClient(
+		context.Background(),
+		"",
+		"test-project",
+		defaults,
+		WithLocalSource(path),
+		WithLogger(&testLogger{}),
+	)
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if !client.Get("local_flag") {
+		t.Error("Expected local_flag to be enabled from file")
+	}
+	if val := client.MustGetValueString("local_value"); val != "from_file" {
+		t.Errorf("Expected 'from_file', got %s", val)
+	}
+
+	t.Run("sync picks up file changes", func(t *testing.T) {
+		writeFile(`{"flags": [{"name": "local_flag", "enabled": false}]}`, now.Add(time.Second))
+
+		if err := client.Sync(); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		if client.Get("local_flag") {
+			t.Error("Expected local_flag to be disabled after file change")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := MakeClient(
+			context.Background(),
+			"",
+			"test-project",
+			defaults,
+			WithLocalSource(filepath.Join(t.TempDir(), "missing.json")),
+		)
+		if err == nil {
+			t.Error("Expected error for missing file")
+		}
+	})
+}
+
+// Test YAML files are rejected explicitly instead of failing to decode as JSON
+func TestLocalSourceYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	if err := os.WriteFile(path, []byte("flags:\n  - name: local_flag\n    enabled: true\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	_, err := MakeClient(context.Background(), "", "test-project", Defaults{},
+		WithLocalSource(path), WithLogger(&testLogger{}))
+	if !errors.Is(err, ErrorUnsupportedFormat) {
+		t.Errorf("Expected ErrorUnsupportedFormat, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package featureflags
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// slogLogger adapts *slog.Logger to the Logger interface for messages
+// which are not structured events, e.g. warnings of value getters
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l *slogLogger) Printf(format string, args ...any) {
+	l.logger.Warn(fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Fatalf(format string, args ...any) {
+	l.logger.Error(fmt.Sprintf(format, args...))
+}
+
+// logEvent logs a client event, e.g. a sync. With WithSlog it is a structured record
+// with attrs and the error, otherwise it is printed with the Logger as
+// "msg: err key=value ...". Events below the level set by WithLogLevel are dropped.
+func (flags *FeatureFlags) logEvent(level slog.Level, msg string, err error, attrs ...slog.Attr) {
+	if level < flags.logLevel {
+		return
+	}
+	if flags.slog != nil {
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+		}
+		flags.slog.LogAttrs(flags.context(), level, msg, attrs...)
+		return
+	}
+
+	if flags.logger == nil {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	if err != nil {
+		fmt.Fprintf(&b, ": %v", err)
+	}
+	for _, attr := range attrs {
+		fmt.Fprintf(&b, " %s", attr)
+	}
+	flags.logger.Printf("%s", b.String())
+}
@@ -0,0 +1,157 @@
+package featureflags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type printLogger struct {
+	messages []string
+}
+
+func (l *printLogger) Printf(format string, args ...any) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (l *printLogger) Fatalf(format string, args ...any) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+// Test sync events are logged as structured records with WithSlog
+func TestSlogSyncEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(LoadFlagsResponse{
+			Version: 3,
+			Flags:   []FlagResponse{{Name: "test_flag", Enabled: true}},
+		})
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	flags, err := MakeClient(context.Background(), server.URL, "test-project",
+		Defaults{Flags: []Flag{{Name: "test_flag"}}}, WithSlog(logger), WithSyncInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	flags.needsLoad.Store(true)
+	if err := flags.syncOnce(context.Background()); err != nil {
+		t.Fatalf("syncOnce failed: %v", err)
+	}
+
+	var record map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("Failed to decode record %q: %v", line, err)
+		}
+		if record["msg"] == "Flags has been loaded" {
+			break
+		}
+	}
+	if record["msg"] != "Flags has been loaded" || record["level"] != "INFO" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+	if record["project"] != "test-project" {
+		t.Errorf("Expected project attr, got %v", record["project"])
+	}
+	if record["version"] != float64(3) {
+		t.Errorf("Expected version 3, got %v", record["version"])
+	}
+	if _, ok := record["duration"]; !ok {
+		t.Errorf("Expected duration attr, got %v", record)
+	}
+}
+
+// Test events are printed with the Logger when slog is not set
+func TestLogEvent(t *testing.T) {
+	t.Run("logger", func(t *testing.T) {
+		logger := &printLogger{}
+		flags := &FeatureFlags{logger: logger}
+		flags.logEvent(slog.LevelWarn, "Could not sync flags", errors.New("timeout"),
+			slog.Int("version", 2), slog.Duration("duration", time.Second))
+
+		want := "Could not sync flags: timeout version=2 duration=1s"
+		if len(logger.messages) != 1 || logger.messages[0] != want {
+			t.Errorf("Expected %q, got %v", want, logger.messages)
+		}
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		var buf bytes.Buffer
+		flags := &FeatureFlags{slog: slog.New(slog.NewTextHandler(&buf, nil))}
+		flags.logEvent(slog.LevelWarn, "Could not sync flags", errors.New("timeout"), slog.Int("version", 2))
+
+		for _, part := range []string{"level=WARN", `msg="Could not sync flags"`, "version=2", "error=timeout"} {
+			if !bytes.Contains(buf.Bytes(), []byte(part)) {
+				t.Errorf("Expected %s in %q", part, buf.String())
+			}
+		}
+	})
+
+	t.Run("slog adapter", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := &slogLogger{logger: slog.New(slog.NewTextHandler(&buf, nil))}
+		logger.Printf("Value %s is undefined", "test_value")
+
+		if !bytes.Contains(buf.Bytes(), []byte(`level=WARN msg="Value test_value is undefined"`)) {
+			t.Errorf("Unexpected output %q", buf.String())
+		}
+	})
+}
+
+// Test syncs without changes are only logged at debug level
+func TestLogLevel(t *testing.T) {
+	var version atomic.Int64
+	version.Store(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(SyncFlagsResponse{Version: int(version.Load())})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		level    slog.Level
+		changed  bool
+		messages int
+	}{
+		{name: "unchanged at info", level: slog.LevelInfo, changed: false, messages: 0},
+		{name: "changed at info", level: slog.LevelInfo, changed: true, messages: 1},
+		{name: "unchanged at debug", level: slog.LevelDebug, changed: false, messages: 1},
+		{name: "changed at warn", level: slog.LevelWarn, changed: true, messages: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &printLogger{}
+			flags := &FeatureFlags{
+				client:   server.Client(),
+				httpAddr: server.URL,
+				project:  "test-project",
+				logger:   logger,
+				logLevel: tt.level,
+			}
+			flags.state.Store(&State{version: int(version.Load())})
+			if tt.changed {
+				version.Add(1)
+			}
+
+			if err := flags.syncOnce(context.Background()); err != nil {
+				t.Fatalf("syncOnce failed: %v", err)
+			}
+			if len(logger.messages) != tt.messages {
+				t.Errorf("Expected %d log messages, got %v", tt.messages, logger.messages)
+			}
+		})
+	}
+}
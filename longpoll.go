@@ -0,0 +1,35 @@
+package featureflags
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// longPollKey marks contexts of syncs sent by the sync loop, only they are held by the server
+type longPollKey struct{}
+
+func withLongPoll(ctx context.Context) context.Context {
+	return context.WithValue(ctx, longPollKey{}, true)
+}
+
+// longPollWait returns the wait in seconds to send in a sync request, rounded up
+func (flags *FeatureFlags) longPollWait(ctx context.Context) int {
+	if flags.longPoll <= 0 || ctx.Value(longPollKey{}) == nil {
+		return 0
+	}
+	return int(math.Ceil(flags.longPoll.Seconds()))
+}
+
+// syncDelay returns the delay before the next sync, after a successful sync which took
+// elapsed and started at version. With long polling the next sync is sent right away
+// if the server has held the request or the version has changed.
+func (flags *FeatureFlags) syncDelay(version int, elapsed time.Duration) time.Duration {
+	if flags.longPoll <= 0 {
+		return flags.syncInterval
+	}
+	if flags.loadState().version != version || elapsed >= flags.longPoll/2 {
+		return 0
+	}
+	return flags.syncInterval
+}
@@ -0,0 +1,73 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test only syncs of the sync loop ask the server to hold requests
+func TestLongPollWait(t *testing.T) {
+	var waits []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SyncFlagsRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		waits = append(waits, req.Wait)
+		json.NewEncoder(w).Encode(SyncFlagsResponse{Version: 1})
+	}))
+	defer server.Close()
+
+	flags := &FeatureFlags{
+		client:   server.Client(),
+		httpAddr: server.URL,
+		project:  "test-project",
+		logger:   &testLogger{},
+		longPoll: 1500 * time.Millisecond,
+	}
+
+	if err := flags.syncOnce(withLongPoll(context.Background())); err != nil {
+		t.Fatalf("syncOnce failed: %v", err)
+	}
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if err := flags.ForceSync(context.Background()); err != nil {
+		t.Fatalf("ForceSync failed: %v", err)
+	}
+
+	if len(waits) != 3 || waits[0] != 2 || waits[1] != 0 || waits[2] != 0 {
+		t.Errorf("Expected waits [2 0 0], got %v", waits)
+	}
+}
+
+// Test the delay before the next sync in long-poll mode
+func TestSyncDelay(t *testing.T) {
+	flags := &FeatureFlags{syncInterval: 10 * time.Second}
+	flags.state.Store(&State{version: 2})
+
+	if delay := flags.syncDelay(2, time.Minute); delay != 10*time.Second {
+		t.Errorf("Expected sync interval without long polling, got %v", delay)
+	}
+
+	flags.longPoll = 30 * time.Second
+	tests := []struct {
+		name     string
+		version  int
+		elapsed  time.Duration
+		expected time.Duration
+	}{
+		{name: "request was held", version: 2, elapsed: 30 * time.Second, expected: 0},
+		{name: "version changed", version: 1, elapsed: time.Millisecond, expected: 0},
+		{name: "no long polling on server", version: 2, elapsed: time.Millisecond, expected: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if delay := flags.syncDelay(tt.version, tt.elapsed); delay != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, delay)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package featureflags
+
+import (
+	"context"
+	"sync"
+)
+
+// memoKey is the context key of the memo of a client, so contexts memoizing lookups
+// of several clients don't mix their results
+type memoKey struct {
+	flags *FeatureFlags
+}
+
+// memo holds the first results of flags looked up with GetContext
+type memo struct {
+	mu    sync.Mutex
+	flags map[string]bool
+}
+
+// Memoize returns a context in which GetContext reuses the first result of every flag,
+// e.g. for the lifetime of a request. A flag checked at the top and at the bottom of a
+// handler then has the same state, even if a Sync lands in between.
+//
+// Only the first lookup of a flag within the context is reported to evaluation hooks.
+func (flags *FeatureFlags) Memoize(ctx context.Context) context.Context {
+	return context.WithValue(ctx, memoKey{flags}, &memo{flags: make(map[string]bool)})
+}
+
+// GetContext is like Get, but returns the forced result if the flag was forced in ctx by
+// ForceContext, or the memoized result if ctx was returned by Memoize and the flag was
+// already looked up within it.
+func (flags *FeatureFlags) GetContext(ctx context.Context, name string) bool {
+	if enabled, ok := ctx.Value(forcedKey{flags, name}).(bool); ok {
+		return enabled
+	}
+	m, ok := ctx.Value(memoKey{flags}).(*memo)
+	if !ok {
+		return flags.Get(name)
+	}
+
+	m.mu.Lock()
+	enabled, found := m.flags[name]
+	m.mu.Unlock()
+	if found {
+		return enabled
+	}
+
+	// Get runs hooks, so it is called without holding the lock. If another goroutine
+	// of the request has looked up the flag meanwhile, its result wins.
+	enabled = flags.Get(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if first, found := m.flags[name]; found {
+		return first
+	}
+	m.flags[name] = enabled
+	return enabled
+}
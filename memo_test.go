@@ -0,0 +1,48 @@
+package featureflags
+
+import (
+	"context"
+	"testing"
+)
+
+// Test GetContext reuses the first result of a flag within a memoized context
+func TestMemoize(t *testing.T) {
+	var evaluations int
+	flags := &FeatureFlags{
+		logger: &testLogger{},
+		hooks: []EvaluationHook{EvaluationHookFunc(func(Evaluation) {
+			evaluations++
+		})},
+	}
+	flags.state.Store(&State{
+		flagState: map[string]FlagState{"test_flag": {Name: "test_flag", Enabled: true}},
+		flagNames: []string{"test_flag"},
+	})
+
+	ctx := flags.Memoize(context.Background())
+	if !flags.GetContext(ctx, "test_flag") {
+		t.Fatal("Expected test_flag to be enabled")
+	}
+
+	flags.update(2, []FlagResponse{{Name: "test_flag", Enabled: false}}, nil)
+	if !flags.GetContext(ctx, "test_flag") {
+		t.Error("Expected the memoized result within the context")
+	}
+	if evaluations != 1 {
+		t.Errorf("Expected a single evaluation reported to hooks, got %d", evaluations)
+	}
+
+	if flags.GetContext(flags.Memoize(context.Background()), "test_flag") {
+		t.Error("Expected the current state in a new context")
+	}
+	if flags.GetContext(context.Background(), "test_flag") {
+		t.Error("Expected the current state without a memo")
+	}
+
+	// Memos of other clients are not used
+	other := &FeatureFlags{logger: &testLogger{}}
+	other.state.Store(&State{flagState: map[string]FlagState{"test_flag": {Name: "test_flag"}}})
+	if other.GetContext(ctx, "test_flag") {
+		t.Error("Expected the state of the other client")
+	}
+}
@@ -0,0 +1,93 @@
+package featureflags
+
+import "context"
+
+// Override sets the flag locally, taking precedence over the server state, temporary
+// overrides and defaults until ClearOverrides is called. It is meant for incident response,
+// e.g. to hot-patch a misbehaving flag from an admin endpoint without waiting for the server.
+// An alias overrides the flag it names.
+func (flags *FeatureFlags) Override(name string, enabled bool) {
+	flags.modify(func(state *State) {
+		name := flags.resolveAlias(state, name)
+		flag := state.flagState[name]
+		flag.Name = name
+		flag.overridden = true
+		flag.override = enabled
+		state.flagState[name] = flag
+	})
+}
+
+// OverrideValue sets the value locally, like Override does for flags.
+func (flags *FeatureFlags) OverrideValue(name string, value any) {
+	flags.modify(func(state *State) {
+		valueState := state.valueState[name]
+		valueState.Name = name
+		valueState.overridden = true
+		valueState.override = value
+		state.valueState[name] = valueState
+	})
+}
+
+// LocalOverride returns the local override of the flag set by Override, including
+// overrides from WithEnvOverrides. ok is false if the flag is not overridden.
+func (flags *FeatureFlags) LocalOverride(name string) (enabled, ok bool) {
+	state := flags.loadState()
+	flag := state.flagState[flags.resolveAlias(state, name)]
+	return flag.override, flag.overridden
+}
+
+// ClearOverride removes the local override of the flag set by Override, the flag returns
+// to the server state.
+func (flags *FeatureFlags) ClearOverride(name string) {
+	flags.modify(func(state *State) {
+		name := flags.resolveAlias(state, name)
+		if flag, ok := state.flagState[name]; ok && flag.overridden {
+			flag.overridden, flag.override = false, false
+			state.flagState[name] = flag
+		}
+	})
+}
+
+// ClearOverrides removes all local overrides set by Override and OverrideValue,
+// flags and values return to the server state.
+func (flags *FeatureFlags) ClearOverrides() {
+	flags.modify(func(state *State) {
+		for name, flag := range state.flagState {
+			if flag.overridden {
+				flag.overridden, flag.override = false, false
+				state.flagState[name] = flag
+			}
+		}
+		for name, value := range state.valueState {
+			if value.overridden {
+				value.overridden, value.override = false, nil
+				state.valueState[name] = value
+			}
+		}
+	})
+}
+
+// forcedKey is the context key of a flag forced by ForceContext
+type forcedKey struct {
+	flags *FeatureFlags
+	name  string
+}
+
+// ForceContext returns a context in which GetContext returns enabled for the flag, without
+// changing the state seen by other callers, e.g. to test both branches of a flag in parallel
+// tests sharing a client. Forced results are not reported to evaluation hooks.
+func (flags *FeatureFlags) ForceContext(ctx context.Context, name string, enabled bool) context.Context {
+	return context.WithValue(ctx, forcedKey{flags, name}, enabled)
+}
+
+// modify applies changes to a copy of the state and publishes it, notifying watchers
+func (flags *FeatureFlags) modify(apply func(state *State)) {
+	flags.mu.Lock()
+	state := flags.loadState()
+	next := state.clone()
+	apply(next)
+	flags.publish(state, next)
+	flags.mu.Unlock()
+
+	flags.watchers.deliver()
+}
@@ -0,0 +1,199 @@
+package featureflags
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Test local overrides take precedence over the server state until cleared
+func TestOverride(t *testing.T) {
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flags.state.Store(&State{
+		version: 1,
+		flagState: map[string]FlagState{
+			"some_flag": {Name: "some_flag", Enabled: true, DefaultEnabled: false},
+		},
+		flagNames: []string{"some_flag"},
+		valueState: map[string]ValueState{
+			"some_value": {Name: "some_value", Value: 10, DefaultValue: 10},
+		},
+		valueNames: []string{"some_value"},
+	})
+
+	var received []FlagChange
+	flags.OnChange(func(changes []FlagChange) {
+		received = append(received, changes...)
+	})
+
+	flags.Override("some_flag", false)
+	flags.OverrideValue("some_value", 20)
+	if flags.Get("some_flag") {
+		t.Error("Expected some_flag to be overridden to false")
+	}
+	if val := flags.MustGetValueInt("some_value"); val != 20 {
+		t.Errorf("Expected some_value to be overridden to 20, got %d", val)
+	}
+	if !flags.IsValueOverridden("some_value") {
+		t.Error("Expected some_value to be reported as overridden")
+	}
+	if len(received) != 1 || received[0].Name != "some_flag" || received[0].Enabled {
+		t.Errorf("Expected a change notification for some_flag, got %v", received)
+	}
+
+	// Overrides survive server updates
+	flags.update(2, []FlagResponse{{Name: "some_flag", Enabled: true}}, []ValueResponse{{Name: "some_value", Value: 30}})
+	if flags.Get("some_flag") {
+		t.Error("Expected some_flag override to survive an update")
+	}
+	if val := flags.MustGetValueInt("some_value"); val != 20 {
+		t.Errorf("Expected some_value override to survive an update, got %d", val)
+	}
+
+	// Overrides of undeclared flags are not pruned
+	flags.Override("new_flag", true)
+	flags.update(3, []FlagResponse{{Name: "some_flag", Enabled: true}}, nil)
+	if !flags.Get("new_flag") {
+		t.Error("Expected new_flag override to survive an update")
+	}
+
+	if enabled, ok := flags.LocalOverride("new_flag"); !enabled || !ok {
+		t.Error("Expected new_flag to report its override")
+	}
+	if _, ok := flags.LocalOverride("other_flag"); ok {
+		t.Error("Expected other_flag not to be overridden")
+	}
+
+	// ClearOverride only clears the named flag
+	flags.ClearOverride("new_flag")
+	if flags.Get("new_flag") {
+		t.Error("Expected new_flag override to be cleared")
+	}
+	if flags.Get("some_flag") {
+		t.Error("Expected some_flag to stay overridden")
+	}
+
+	flags.ClearOverrides()
+	if !flags.Get("some_flag") {
+		t.Error("Expected some_flag to return to the server state")
+	}
+	if val := flags.MustGetValueInt("some_value"); val != 30 {
+		t.Errorf("Expected some_value to return to the server value, got %d", val)
+	}
+	if flags.Get("new_flag") {
+		t.Error("Expected new_flag override to be cleared")
+	}
+}
+
+// Test overrides by an alias apply to the flag it names
+func TestOverrideAlias(t *testing.T) {
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flags.state.Store(&State{
+		version: 1,
+		flagState: map[string]FlagState{
+			"new_checkout": {Name: "new_checkout", Enabled: true},
+		},
+		flagNames:  []string{"new_checkout"},
+		valueState: map[string]ValueState{},
+		aliases:    map[string]string{"checkout_v2": "new_checkout"},
+	})
+
+	flags.Override("checkout_v2", false)
+	if flags.Get("new_checkout") {
+		t.Error("Expected the override of checkout_v2 to apply to new_checkout")
+	}
+	if _, exists := flags.loadState().flagState["checkout_v2"]; exists {
+		t.Error("Expected no flag to be created for the alias")
+	}
+	if enabled, ok := flags.LocalOverride("checkout_v2"); enabled || !ok {
+		t.Error("Expected checkout_v2 to report the override of new_checkout")
+	}
+	if _, ok := flags.EvaluateAll()["checkout_v2"]; ok {
+		t.Error("Expected EvaluateAll not to report the alias")
+	}
+
+	flags.ClearOverride("checkout_v2")
+	if _, ok := flags.LocalOverride("new_checkout"); ok || !flags.Get("new_checkout") {
+		t.Error("Expected clearing checkout_v2 to clear the override of new_checkout")
+	}
+}
+
+// Test flags forced in a context apply only to GetContext with that context and client
+func TestForceContext(t *testing.T) {
+	newFlags := func() *FeatureFlags {
+		flags := &FeatureFlags{logger: &testLogger{}}
+		flags.state.Store(&State{
+			version:    1,
+			flagState:  map[string]FlagState{"some_flag": {Name: "some_flag", Enabled: true}},
+			flagNames:  []string{"some_flag"},
+			valueState: map[string]ValueState{},
+		})
+		return flags
+	}
+	flags, other := newFlags(), newFlags()
+
+	ctx := flags.ForceContext(context.Background(), "some_flag", false)
+	if flags.GetContext(ctx, "some_flag") {
+		t.Error("Expected some_flag to be forced in the context")
+	}
+	if !flags.Get("some_flag") || !flags.GetContext(context.Background(), "some_flag") {
+		t.Error("Expected some_flag not to be forced outside of the context")
+	}
+	if !other.GetContext(ctx, "some_flag") {
+		t.Error("Expected some_flag not to be forced for another client")
+	}
+
+	memoized := flags.Memoize(ctx)
+	if flags.GetContext(memoized, "some_flag") {
+		t.Error("Expected the forced flag to take precedence over the memo")
+	}
+	if !flags.GetContext(flags.ForceContext(memoized, "some_flag", true), "some_flag") {
+		t.Error("Expected the innermost force to win")
+	}
+}
+
+// Test callbacks can override flags, their changes are delivered after them in order
+func TestOverrideFromCallback(t *testing.T) {
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flags.state.Store(&State{
+		version: 1,
+		flagState: map[string]FlagState{
+			"some_flag":      {Name: "some_flag", Enabled: true},
+			"dependent_flag": {Name: "dependent_flag", Enabled: true},
+		},
+		flagNames: []string{"some_flag", "dependent_flag"},
+	})
+
+	var received []FlagChange
+	flags.OnChange(func(changes []FlagChange) {
+		received = append(received, changes...)
+		for _, change := range changes {
+			if change.Name == "some_flag" && !change.Enabled {
+				flags.Override("dependent_flag", false)
+			}
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		flags.update(2, []FlagResponse{{Name: "some_flag", Enabled: false}}, nil)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Override from a callback not to block")
+	}
+
+	if flags.Get("dependent_flag") {
+		t.Error("Expected dependent_flag to be overridden by the callback")
+	}
+	expected := []FlagChange{
+		{Name: "some_flag", Enabled: false, Previous: true, Sequence: 1},
+		{Name: "dependent_flag", Enabled: false, Previous: true, Sequence: 2},
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected changes %v, got %v", expected, received)
+	}
+}
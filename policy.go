@@ -0,0 +1,77 @@
+package featureflags
+
+import "fmt"
+
+// ErrorAction defines how the client reacts to a misuse of flags or values
+type ErrorAction int
+
+const (
+	ErrorIgnore ErrorAction = iota + 1 // 1, silently use the fallback
+	ErrorLog                           // 2, log and use the fallback
+	ErrorPanic                         // 3, panic
+)
+
+// ErrorPolicy configures reactions to misuses of flags and values in Get and MustGetValue*
+// getters. Getters returning errors are not affected. Unset fields use the default action.
+type ErrorPolicy struct {
+	// UndefinedValue is a MustGetValue* call for a value which was never defined in defaults,
+	// or whose default has a wrong type. The zero value of the type is used as the fallback.
+	// Default: ErrorPanic, as it is a programming error.
+	UndefinedValue ErrorAction
+	// TypeMismatch is a value from the server which can't be cast to the requested type,
+	// the default value is used as the fallback. Default: ErrorLog.
+	TypeMismatch ErrorAction
+	// UnknownFlag is a Get call for a flag which was never defined in defaults nor
+	// returned by the server, false is used as the fallback. Default: ErrorIgnore.
+	UnknownFlag ErrorAction
+}
+
+var (
+	// StrictErrorPolicy panics on any misuse, to catch mistakes in tests and development
+	StrictErrorPolicy = ErrorPolicy{
+		UndefinedValue: ErrorPanic,
+		TypeMismatch:   ErrorPanic,
+		UnknownFlag:    ErrorPanic,
+	}
+	// LenientErrorPolicy silently uses fallbacks
+	LenientErrorPolicy = ErrorPolicy{
+		UndefinedValue: ErrorIgnore,
+		TypeMismatch:   ErrorIgnore,
+		UnknownFlag:    ErrorIgnore,
+	}
+	// ProductionErrorPolicy never panics, and logs every misuse
+	ProductionErrorPolicy = ErrorPolicy{
+		UndefinedValue: ErrorLog,
+		TypeMismatch:   ErrorLog,
+		UnknownFlag:    ErrorLog,
+	}
+)
+
+// handleError reacts to a misuse according to the action, falling back to the default action
+func (flags *FeatureFlags) handleError(action, defaultAction ErrorAction, format string, args ...any) {
+	if action == 0 {
+		action = defaultAction
+	}
+	switch action {
+	case ErrorPanic:
+		panic(fmt.Sprintf(format, args...))
+	case ErrorLog:
+		flags.logger.Printf(format, args...)
+	}
+}
+
+func (flags *FeatureFlags) undefinedValue(format string, args ...any) {
+	flags.handleError(flags.errorPolicy.UndefinedValue, ErrorPanic, format, args...)
+}
+
+func (flags *FeatureFlags) typeMismatch(format string, args ...any) {
+	flags.handleError(flags.errorPolicy.TypeMismatch, ErrorLog, format, args...)
+}
+
+func (flags *FeatureFlags) unknownFlag(name string) {
+	// Unknown flags are ignored by default, return early so Get doesn't allocate the arguments
+	if action := flags.errorPolicy.UnknownFlag; action == 0 || action == ErrorIgnore {
+		return
+	}
+	flags.handleError(flags.errorPolicy.UnknownFlag, ErrorIgnore, "flag %s was never defined in defaults", name)
+}
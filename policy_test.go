@@ -0,0 +1,99 @@
+package featureflags
+
+import "testing"
+
+// Test reactions to misuses under different error policies
+func TestErrorPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   ErrorPolicy
+		call     func(flags *FeatureFlags)
+		panics   bool
+		messages int
+	}{
+		{
+			name:   "default undefined value panics",
+			call:   func(flags *FeatureFlags) { flags.MustGetValueInt("missing") },
+			panics: true,
+		},
+		{
+			name:     "default type mismatch logs",
+			call:     func(flags *FeatureFlags) { flags.MustGetValueInt("string_value") },
+			messages: 1,
+		},
+		{
+			name: "default unknown flag is ignored",
+			call: func(flags *FeatureFlags) { flags.Get("missing") },
+		},
+		{
+			name:   "strict unknown flag panics",
+			policy: StrictErrorPolicy,
+			call:   func(flags *FeatureFlags) { flags.Get("missing") },
+			panics: true,
+		},
+		{
+			name:   "strict type mismatch panics",
+			policy: StrictErrorPolicy,
+			call:   func(flags *FeatureFlags) { flags.MustGetValueInt("string_value") },
+			panics: true,
+		},
+		{
+			name:   "lenient undefined value is ignored",
+			policy: LenientErrorPolicy,
+			call: func(flags *FeatureFlags) {
+				if val := flags.MustGetValueString("missing"); val != "" {
+					t.Errorf("Expected zero value, got %q", val)
+				}
+			},
+		},
+		{
+			name:     "production undefined value logs",
+			policy:   ProductionErrorPolicy,
+			call:     func(flags *FeatureFlags) { flags.MustGetValueBool("missing") },
+			messages: 1,
+		},
+		{
+			name:     "production unknown payload flag logs",
+			policy:   ProductionErrorPolicy,
+			call:     func(flags *FeatureFlags) { flags.GetFlagPayload("missing") },
+			messages: 1,
+		},
+		{
+			name:   "partial policy keeps other defaults",
+			policy: ErrorPolicy{UnknownFlag: ErrorLog},
+			call:   func(flags *FeatureFlags) { flags.MustGetValueInt("missing") },
+			panics: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &testLogger{}
+			flags := &FeatureFlags{
+				logger:      logger,
+				errorPolicy: tt.policy,
+			}
+			flags.state.Store(&State{
+				flagState: map[string]FlagState{},
+				valueState: map[string]ValueState{
+					"string_value": {Name: "string_value", Value: "text", DefaultValue: 5, IsOverridden: true},
+				},
+			})
+
+			panicked := func() (panicked bool) {
+				defer func() {
+					panicked = recover() != nil
+				}()
+				tt.call(flags)
+				return false
+			}()
+
+			if panicked != tt.panics {
+				t.Errorf("Expected panic: %v, got: %v", tt.panics, panicked)
+			}
+			if len(logger.messages) != tt.messages {
+				t.Errorf("Expected %d log messages, got %v", tt.messages, logger.messages)
+			}
+		})
+	}
+}
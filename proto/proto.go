@@ -0,0 +1,114 @@
+// Package proto defines wire types of the feature flags server HTTP API. It has no
+// dependencies on the client runtime, so tools like admin CLIs and relays can import it.
+//
+// The protocol is versioned by SchemaVersion. Fields added within a schema version are
+// optional (omitempty) and ignored by older peers, so they are backward compatible:
+//
+//   - expires_at of flags and values: temporary overrides
+//   - starts_at and ends_at of flags: activation windows
+//   - payload of flags: lists of strings carried by enabled flags
+//   - aliases of flags: former names of renamed flags
+//   - wait of sync requests: long polling
+//   - /flags/events: exposure events, servers without it reply with an error
+//   - ETag and If-None-Match headers of load and sync: 304 Not Modified replies to syncs
+//
+// Removing or changing the meaning of a field requires a new schema version.
+package proto
+
+import "time"
+
+// SchemaVersion is the version of the wire format defined by this package
+const SchemaVersion = 1
+
+type VariableType int
+
+const (
+	TypeString    VariableType = iota + 1 // 1
+	TypeNumber                            // 2
+	TypeTimestamp                         // 3
+	TypeSet                               // 4
+)
+
+type Variable struct {
+	Name string       `json:"name"`
+	Type VariableType `json:"type"`
+}
+
+// SyncFlagsRequest is sent to /flags/sync to fetch changes since Version
+type SyncFlagsRequest struct {
+	Project string   `json:"project"`
+	Version int      `json:"version"`
+	Flags   []string `json:"flags"`
+	Values  []string `json:"values"`
+	// Wait asks the server to hold the request for up to Wait seconds until
+	// the version changes, servers without long polling reply immediately
+	Wait int `json:"wait,omitempty"`
+}
+
+// SyncFlagsResponse is the reply to SyncFlagsRequest
+type SyncFlagsResponse struct {
+	Version int             `json:"version"`
+	Flags   []FlagResponse  `json:"flags"`
+	Values  []ValueResponse `json:"values"`
+}
+
+// LoadFlagsRequest is sent to /flags/load to create the project, its flags, values and
+// variables on the server, and to fetch the current state
+type LoadFlagsRequest struct {
+	Project   string       `json:"project"`
+	Version   int          `json:"version"`
+	Variables []Variable   `json:"variables"`
+	Flags     []string     `json:"flags"`
+	Values    []ValueInput `json:"values"`
+}
+
+// LoadFlagsResponse is the reply to LoadFlagsRequest
+type LoadFlagsResponse struct {
+	Version int             `json:"version"`
+	Flags   []FlagResponse  `json:"flags"`
+	Values  []ValueResponse `json:"values"`
+}
+
+type FlagResponse struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// ExpiresAt marks a temporary override, the client reverts to the default after it
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// StartsAt and EndsAt limit the flag state to an activation window, the client uses
+	// the default outside of it. Either can be nil for a window open on that side.
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	// Payload is an optional list of strings carried by the flag
+	Payload []string `json:"payload,omitempty"`
+	// Aliases are former names of the flag, which still resolve to it
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+type ValueResponse struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"` // Using interface{} for Any type
+	// ExpiresAt marks a temporary override, the client reverts to the default after it
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type ValueInput struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// ExposureEvent is a single flag or value evaluation
+type ExposureEvent struct {
+	Kind      string      `json:"kind"` // "flag" or "value"
+	Name      string      `json:"name"`
+	Result    interface{} `json:"result"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// ReportEventsRequest is sent to /flags/events with a batch of exposure events.
+// Dropped is the number of events which were discarded since the previous batch
+// because the client buffer was full.
+type ReportEventsRequest struct {
+	Project string          `json:"project"`
+	Events  []ExposureEvent `json:"events"`
+	Dropped int             `json:"dropped,omitempty"`
+}
@@ -0,0 +1,108 @@
+package proto
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// Test requests are encoded in the schema understood by the server
+func TestEncodeRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  any
+		expected string
+	}{
+		{
+			name: "sync",
+			request: SyncFlagsRequest{
+				Project: "test-project",
+				Version: 3,
+				Flags:   []string{"some_flag"},
+				Values:  []string{"some_value"},
+			},
+			expected: `{"project":"test-project","version":3,"flags":["some_flag"],"values":["some_value"]}`,
+		},
+		{
+			name: "load",
+			request: LoadFlagsRequest{
+				Project:   "test-project",
+				Version:   0,
+				Variables: []Variable{{Name: "user.id", Type: TypeNumber}},
+				Flags:     []string{"some_flag"},
+				Values:    []ValueInput{{Name: "some_value", Value: 10}},
+			},
+			expected: `{"project":"test-project","version":0,"variables":[{"name":"user.id","type":2}],` +
+				`"flags":["some_flag"],"values":[{"name":"some_value","value":10}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.request)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, data)
+			}
+		})
+	}
+}
+
+// Test responses of the initial schema and with optional extensions are decoded
+func TestDecodeResponses(t *testing.T) {
+	t.Run("initial schema", func(t *testing.T) {
+		var res SyncFlagsResponse
+		data := `{"version":2,"flags":[{"name":"some_flag","enabled":true}],"values":[{"name":"some_value","value":"text"}]}`
+		if err := json.Unmarshal([]byte(data), &res); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if res.Version != 2 || len(res.Flags) != 1 || !res.Flags[0].Enabled || res.Flags[0].ExpiresAt != nil {
+			t.Errorf("Unexpected flags: %+v", res)
+		}
+		if len(res.Values) != 1 || res.Values[0].Value != "text" {
+			t.Errorf("Unexpected values: %+v", res.Values)
+		}
+	})
+
+	t.Run("extensions", func(t *testing.T) {
+		var res LoadFlagsResponse
+		data := `{"version":2,"flags":[{"name":"some_flag","enabled":true,"expires_at":"2030-01-01T00:00:00Z",` +
+			`"payload":["a"],"aliases":["old_flag"]}],"values":[]}`
+		if err := json.Unmarshal([]byte(data), &res); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		flag := res.Flags[0]
+		if flag.ExpiresAt == nil || !flag.ExpiresAt.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected expires_at: %v", flag.ExpiresAt)
+		}
+		if len(flag.Payload) != 1 || len(flag.Aliases) != 1 {
+			t.Errorf("Unexpected flag: %+v", flag)
+		}
+	})
+
+	t.Run("unknown fields are ignored", func(t *testing.T) {
+		var res SyncFlagsResponse
+		data := `{"version":2,"schema":2,"flags":[{"name":"some_flag","enabled":true,"rules":[]}],"values":[]}`
+		if err := json.Unmarshal([]byte(data), &res); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+	})
+}
+
+// Test optional extensions are omitted when unset, so older servers see the initial schema
+func TestOmitExtensions(t *testing.T) {
+	data, err := json.Marshal(SyncFlagsResponse{
+		Version: 1,
+		Flags:   []FlagResponse{{Name: "some_flag", Enabled: true}},
+		Values:  []ValueResponse{{Name: "some_value", Value: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	expected := `{"version":1,"flags":[{"name":"some_flag","enabled":true}],"values":[{"name":"some_value","value":1}]}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
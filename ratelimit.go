@@ -0,0 +1,55 @@
+package featureflags
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// parseRetryAfter returns the delay of a Retry-After header, which is either a number of
+// seconds or an HTTP date, or zero if the header is missing or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// rateLimited records the Retry-After hint of a failed request, so the sync loop
+// doesn't send requests before it
+func (flags *FeatureFlags) rateLimited(err error) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		flags.rateLimitedUntil.Store(time.Now().Add(httpErr.RetryAfter).UnixNano())
+	}
+}
+
+// rateLimitWait returns how long the server asked to wait before the next request
+func (flags *FeatureFlags) rateLimitWait() time.Duration {
+	nanos := flags.rateLimitedUntil.Load()
+	if nanos == 0 {
+		return 0
+	}
+	return max(time.Until(time.Unix(0, nanos)), 0)
+}
+
+// RateLimitedUntil returns the time until which the server asked not to send requests
+// with a Retry-After header of a 429 or 503 reply. It is zero unless the last failed
+// request was rate limited, and is reset by the next successful Load or Sync.
+func (flags *FeatureFlags) RateLimitedUntil() time.Time {
+	nanos := flags.rateLimitedUntil.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
@@ -0,0 +1,73 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Test Retry-After is parsed as seconds or as an HTTP date
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-1", 0},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+// Test the sync loop waits for the Retry-After hint, even when a sync is triggered
+func TestSyncLoopRetryAfter(t *testing.T) {
+	var syncs atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flags/sync" {
+			syncs.Add(1)
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(LoadFlagsResponse{Version: 1})
+	}))
+	defer server.Close()
+
+	client, err := MakeClient(context.Background(), server.URL, "test-project", Defaults{},
+		WithSyncInterval(time.Millisecond), WithBackoff(time.Millisecond, time.Millisecond),
+		WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if !client.RateLimitedUntil().IsZero() {
+		t.Error("Expected no rate limit before the first sync")
+	}
+	deadline := time.Now().Add(time.Second)
+	for syncs.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	client.TriggerSync()
+	time.Sleep(50 * time.Millisecond)
+
+	if syncs.Load() != 1 {
+		t.Errorf("Expected a single sync before Retry-After has passed, got %d", syncs.Load())
+	}
+	if until := time.Until(client.RateLimitedUntil()); until < 50*time.Second || until > time.Minute {
+		t.Errorf("Expected rate limit for about a minute, got %s", until)
+	}
+}
@@ -0,0 +1,499 @@
+package featureflags
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxRelayBody limits the size of load and sync requests read by the relay
+const maxRelayBody = 1 << 20
+
+// maxRelayProjects limits the number of projects a relay keeps state for, as clients
+// choose project names freely
+const maxRelayProjects = 1000
+
+// relayProjectIdle is the time after which a project no client has asked for is dropped
+const relayProjectIdle = time.Hour
+
+// relayStaleSyncs is the number of sync intervals after which a project which hasn't
+// synced with the server makes the relay unhealthy
+const relayStaleSyncs = 3
+
+// Relay serves the load and sync protocol to co-located clients, e.g. as a sidecar, so a
+// node keeps a single connection to the feature flags server instead of one per process.
+//
+// Loads are forwarded to the server, as they create projects, flags and values there.
+// Syncs are answered from the state the relay keeps for every project it has seen, which
+// Run syncs with the server for the names requested by all clients of the project. Syncs
+// are answered immediately, so clients using WithLongPoll fall back to the sync interval.
+//
+// The relay keeps state for up to 1000 projects. Projects which no client has loaded or
+// synced for an hour are dropped, and requests for new projects beyond the limit are
+// rejected with 503 Service Unavailable.
+//
+// GET /healthz and GET /metrics report sync health and project versions, so a fleet of
+// relays can be monitored like any other service.
+type Relay struct {
+	// upstream is only used as a transport: the HTTP client, headers, breaker and logging
+	upstream     *FeatureFlags
+	syncInterval time.Duration
+	// errors counts failed loads and syncs with the server
+	errors atomic.Uint64
+
+	mu       sync.Mutex
+	projects map[string]*relayProject
+}
+
+// relayProject is the state of a project, limited to the names requested by clients
+type relayProject struct {
+	mu         sync.Mutex
+	synced     time.Time // of the last successful load or sync with the server
+	requested  time.Time // of the last load or sync by a client
+	version    int
+	flags      map[string]FlagResponse
+	values     map[string]ValueResponse
+	flagNames  []string
+	valueNames []string
+}
+
+// NewRelay creates a relay to the feature flags server at httpAddr. Of the client options
+// only those of the transport apply: WithSyncInterval, WithRequestTimeout, request headers
+// and authentication, WithCircuitBreaker, WithRequestCompression, WithMaxResponseSize,
+// WithLogger, WithSlog and WithLogLevel.
+// Requests to the server are cancelled when ctx is done.
+func NewRelay(ctx context.Context, httpAddr string, opts ...ClientOption) (*Relay, error) {
+	config := &ClientConfig{
+		syncInterval:   defaultSyncInterval,
+		requestTimeout: defaultRequestTimeout,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if err := config.validate(httpAddr); err != nil {
+		return nil, err
+	}
+	if config.slog != nil {
+		config.logger = &slogLogger{logger: config.slog}
+	}
+	if config.logger == nil {
+		config.logger = &defaultLogger{}
+	}
+	if config.syncInterval <= 0 {
+		config.syncInterval = defaultSyncInterval
+	}
+	if config.requestTimeout <= 0 {
+		config.requestTimeout = defaultRequestTimeout
+	}
+	if config.maxResponse == 0 {
+		config.maxResponse = defaultMaxResponseSize
+	}
+
+	client, httpAddr := newHTTPClient(httpAddr, config.requestTimeout)
+	return &Relay{
+		upstream: &FeatureFlags{
+			client:      client,
+			httpAddr:    httpAddr,
+			headers:     config.headers,
+			breaker:     config.breaker,
+			compress:    config.compress,
+			maxResponse: config.maxResponse,
+			logger:      config.logger,
+			slog:        config.slog,
+			logLevel:    config.logLevel,
+			ctx:         ctx,
+		},
+		syncInterval: config.syncInterval,
+		projects:     make(map[string]*relayProject),
+	}, nil
+}
+
+// ServeRelay serves a relay to the server at httpAddr on addr until ctx is done. The address
+// is either a TCP address, e.g. "localhost:8081", or a Unix socket path prefixed by "unix:".
+// Clients connect to it by passing the same address to MakeClient.
+func ServeRelay(ctx context.Context, addr, httpAddr string, opts ...ClientOption) error {
+	relay, err := NewRelay(ctx, httpAddr, opts...)
+	if err != nil {
+		return err
+	}
+
+	network := "tcp"
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, addr = "unix", path
+	}
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: relay}
+	go relay.Run(ctx)
+	stop := context.AfterFunc(ctx, func() {
+		server.Shutdown(context.Background())
+	})
+	defer stop()
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newHTTPClient returns the client for requests to httpAddr and the base URL of requests.
+// A "unix:" address is a Unix socket, e.g. of a relay.
+func newHTTPClient(httpAddr string, timeout time.Duration) (*http.Client, string) {
+	client := &http.Client{Timeout: timeout}
+	path, ok := strings.CutPrefix(httpAddr, "unix:")
+	if !ok {
+		return client, httpAddr
+	}
+	var dialer net.Dialer
+	client.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}
+	return client, "http://unix"
+}
+
+// Run syncs the state of every project with the server every sync interval, until ctx is done
+func (relay *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		relay.syncAll(ctx)
+	}
+}
+
+func (relay *Relay) syncAll(ctx context.Context) {
+	relay.mu.Lock()
+	projects := make(map[string]*relayProject, len(relay.projects))
+	for name, project := range relay.projects {
+		if project.idle() {
+			delete(relay.projects, name)
+			continue
+		}
+		projects[name] = project
+	}
+	relay.mu.Unlock()
+
+	for name, project := range projects {
+		if err := relay.sync(ctx, name, project, false); err != nil {
+			relay.errors.Add(1)
+			relay.upstream.logEvent(slog.LevelWarn, "Could not sync relayed flags", err,
+				slog.String("project", name))
+		}
+	}
+}
+
+// project returns the state of the named project, creating an empty one on first use.
+// ok is false if the relay already keeps maxRelayProjects projects.
+func (relay *Relay) project(name string) (project *relayProject, ok bool) {
+	relay.mu.Lock()
+	defer relay.mu.Unlock()
+	project, ok = relay.projects[name]
+	if !ok {
+		if len(relay.projects) >= maxRelayProjects {
+			return nil, false
+		}
+		project = &relayProject{
+			flags:     make(map[string]FlagResponse),
+			values:    make(map[string]ValueResponse),
+			requested: time.Now(),
+		}
+		relay.projects[name] = project
+	}
+	return project, true
+}
+
+// idle reports whether no client has asked for the project for relayProjectIdle
+func (project *relayProject) idle() bool {
+	project.mu.Lock()
+	defer project.mu.Unlock()
+	return time.Since(project.requested) > relayProjectIdle
+}
+
+// sync fetches the state of the project from the server. A full sync asks for the state
+// as of version 0, so names which were just added are sent even if the version is the same.
+func (relay *Relay) sync(ctx context.Context, name string, project *relayProject, full bool) error {
+	project.mu.Lock()
+	req := SyncFlagsRequest{
+		Project: name,
+		Version: project.version,
+		Flags:   slices.Clone(project.flagNames),
+		Values:  slices.Clone(project.valueNames),
+	}
+	project.mu.Unlock()
+	if full {
+		req.Version = 0
+	}
+
+	var reply SyncFlagsResponse
+	if err := relay.upstream.post(ctx, "/flags/sync", req, &reply); err != nil {
+		return err
+	}
+	project.update(reply.Version, reply.Flags, reply.Values)
+	return nil
+}
+
+// addNames adds names requested by a client and reports whether any of them is new
+func (project *relayProject) addNames(flagNames, valueNames []string) bool {
+	project.mu.Lock()
+	defer project.mu.Unlock()
+	project.requested = time.Now()
+	added := false
+	for _, name := range flagNames {
+		if !slices.Contains(project.flagNames, name) {
+			project.flagNames = append(project.flagNames, name)
+			added = true
+		}
+	}
+	for _, name := range valueNames {
+		if !slices.Contains(project.valueNames, name) {
+			project.valueNames = append(project.valueNames, name)
+			added = true
+		}
+	}
+	return added
+}
+
+func (project *relayProject) update(version int, flags []FlagResponse, values []ValueResponse) {
+	project.mu.Lock()
+	defer project.mu.Unlock()
+	project.version = version
+	project.synced = time.Now()
+	for _, flag := range flags {
+		project.flags[flag.Name] = flag
+	}
+	for _, value := range values {
+		project.values[value.Name] = value
+	}
+}
+
+// reply returns the state of the requested names, or only the version if the client is
+// up to date. ok is false until the project was fetched from the server.
+func (project *relayProject) reply(version int, flagNames, valueNames []string) (reply SyncFlagsResponse, ok bool) {
+	project.mu.Lock()
+	defer project.mu.Unlock()
+	if project.version == 0 {
+		return reply, false
+	}
+	reply.Version = project.version
+	if version == project.version {
+		return reply, true
+	}
+	for _, name := range flagNames {
+		if flag, ok := project.flags[name]; ok {
+			reply.Flags = append(reply.Flags, flag)
+		}
+	}
+	for _, name := range valueNames {
+		if value, ok := project.values[name]; ok {
+			reply.Values = append(reply.Values, value)
+		}
+	}
+	return reply, true
+}
+
+// ServeHTTP handles load and sync requests of clients, and health and metrics requests
+func (relay *Relay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/healthz":
+		relay.serveHealth(w)
+		return
+	case "/metrics":
+		relay.serveMetrics(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxRelayBody)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		// Limit the decompressed body as well
+		body = io.LimitReader(gz, maxRelayBody)
+	}
+	switch r.URL.Path {
+	case "/flags/load":
+		var req LoadFlagsRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		relay.serveLoad(w, r.Context(), req)
+	case "/flags/sync":
+		var req SyncFlagsRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		relay.serveSync(w, r.Context(), req)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveLoad forwards the load to the server, or answers it from the state of the
+// project if the server is unreachable
+func (relay *Relay) serveLoad(w http.ResponseWriter, ctx context.Context, req LoadFlagsRequest) {
+	project, ok := relay.project(req.Project)
+	if !ok {
+		http.Error(w, "too many projects", http.StatusServiceUnavailable)
+		return
+	}
+	valueNames := make([]string, len(req.Values))
+	for i, value := range req.Values {
+		valueNames[i] = value.Name
+	}
+	project.addNames(req.Flags, valueNames)
+
+	var reply LoadFlagsResponse
+	err := relay.upstream.post(ctx, "/flags/load", req, &reply)
+	if err == nil {
+		project.update(reply.Version, reply.Flags, reply.Values)
+		writeRelayReply(w, reply)
+		return
+	}
+	relay.errors.Add(1)
+
+	cached, ok := project.reply(0, req.Flags, valueNames)
+	if !ok {
+		relay.upstream.logEvent(slog.LevelWarn, "Could not relay flags load", err,
+			slog.String("project", req.Project))
+		http.Error(w, "can not load flags", http.StatusBadGateway)
+		return
+	}
+	relay.upstream.logEvent(slog.LevelWarn, "Could not relay flags load, using relayed state", err,
+		slog.String("project", req.Project))
+	writeRelayReply(w, LoadFlagsResponse(cached))
+}
+
+// serveSync answers the sync from the state of the project. Names which the relay has
+// not synced yet, e.g. after a restart of the relay, are fetched from the server first.
+func (relay *Relay) serveSync(w http.ResponseWriter, ctx context.Context, req SyncFlagsRequest) {
+	project, ok := relay.project(req.Project)
+	if !ok {
+		http.Error(w, "too many projects", http.StatusServiceUnavailable)
+		return
+	}
+	if project.addNames(req.Flags, req.Values) {
+		if err := relay.sync(ctx, req.Project, project, true); err != nil {
+			relay.errors.Add(1)
+			relay.upstream.logEvent(slog.LevelWarn, "Could not sync relayed flags", err,
+				slog.String("project", req.Project))
+		}
+	}
+
+	reply, ok := project.reply(req.Version, req.Flags, req.Values)
+	if !ok {
+		http.Error(w, "can not sync flags", http.StatusBadGateway)
+		return
+	}
+	writeRelayReply(w, reply)
+}
+
+// relayProjectStatus is the sync health of a project
+type relayProjectStatus struct {
+	name    string
+	version int
+	synced  time.Time
+}
+
+// status returns the sync health of all projects, sorted by name
+func (relay *Relay) status() []relayProjectStatus {
+	relay.mu.Lock()
+	statuses := make([]relayProjectStatus, 0, len(relay.projects))
+	projects := make([]*relayProject, 0, len(relay.projects))
+	for name, project := range relay.projects {
+		statuses = append(statuses, relayProjectStatus{name: name})
+		projects = append(projects, project)
+	}
+	relay.mu.Unlock()
+
+	for i, project := range projects {
+		project.mu.Lock()
+		statuses[i].version, statuses[i].synced = project.version, project.synced
+		project.mu.Unlock()
+	}
+	slices.SortFunc(statuses, func(a, b relayProjectStatus) int { return strings.Compare(a.name, b.name) })
+	return statuses
+}
+
+// serveHealth reports whether every project has synced with the server recently.
+// Projects which never synced, e.g. requested with a wrong name, are not counted.
+func (relay *Relay) serveHealth(w http.ResponseWriter) {
+	var stale []string
+	for _, status := range relay.status() {
+		if !status.synced.IsZero() && time.Since(status.synced) > relayStaleSyncs*relay.syncInterval {
+			stale = append(stale, status.name)
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if len(stale) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "stale projects: %s\n", strings.Join(stale, ", "))
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// relayLabel escapes a label value of the Prometheus text format
+var relayLabel = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// serveMetrics writes sync health in the Prometheus text format
+func (relay *Relay) serveMetrics(w http.ResponseWriter) {
+	statuses := relay.status()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	fmt.Fprintln(w, "# HELP featureflags_relay_projects Projects relayed to the server.")
+	fmt.Fprintln(w, "# TYPE featureflags_relay_projects gauge")
+	fmt.Fprintf(w, "featureflags_relay_projects %d\n", len(statuses))
+	fmt.Fprintln(w, "# HELP featureflags_relay_sync_errors_total Failed loads and syncs with the server.")
+	fmt.Fprintln(w, "# TYPE featureflags_relay_sync_errors_total counter")
+	fmt.Fprintf(w, "featureflags_relay_sync_errors_total %d\n", relay.errors.Load())
+
+	fmt.Fprintln(w, "# HELP featureflags_relay_project_version Version of the project state.")
+	fmt.Fprintln(w, "# TYPE featureflags_relay_project_version gauge")
+	for _, status := range statuses {
+		fmt.Fprintf(w, "featureflags_relay_project_version{project=\"%s\"} %d\n", relayLabel.Replace(status.name), status.version)
+	}
+	fmt.Fprintln(w, "# HELP featureflags_relay_project_last_sync_timestamp_seconds Time of the last successful sync of the project.")
+	fmt.Fprintln(w, "# TYPE featureflags_relay_project_last_sync_timestamp_seconds gauge")
+	for _, status := range statuses {
+		if status.synced.IsZero() {
+			continue
+		}
+		fmt.Fprintf(w, "featureflags_relay_project_last_sync_timestamp_seconds{project=\"%s\"} %d\n",
+			relayLabel.Replace(status.name), status.synced.Unix())
+	}
+}
+
+func writeRelayReply(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,249 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// relayUpstream is a flags server which replies with the requested flags
+type relayUpstream struct {
+	loads   atomic.Int32
+	syncs   atomic.Int32
+	version atomic.Int64
+	enabled atomic.Bool
+}
+
+func (u *relayUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req SyncFlagsRequest
+	var load LoadFlagsRequest
+	switch r.URL.Path {
+	case "/flags/load":
+		u.loads.Add(1)
+		json.NewDecoder(r.Body).Decode(&load)
+		req.Flags = load.Flags
+	case "/flags/sync":
+		u.syncs.Add(1)
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+	reply := SyncFlagsResponse{Version: int(u.version.Load())}
+	for _, name := range req.Flags {
+		reply.Flags = append(reply.Flags, FlagResponse{Name: name, Enabled: u.enabled.Load()})
+	}
+	json.NewEncoder(w).Encode(reply)
+}
+
+// Test clients behind a relay load through it and sync from its state
+func TestRelay(t *testing.T) {
+	upstream := &relayUpstream{}
+	upstream.version.Store(1)
+	upstreamServer := httptest.NewServer(upstream)
+	defer upstreamServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	relay, err := NewRelay(ctx, upstreamServer.URL, WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("NewRelay failed: %v", err)
+	}
+	relayServer := httptest.NewServer(relay)
+	defer relayServer.Close()
+
+	newClient := func(name string) *FeatureFlags {
+		flags, err := MakeClient(ctx, relayServer.URL, "test-project",
+			Defaults{Flags: []Flag{{Name: name}}},
+			WithSyncInterval(time.Hour), WithLogger(&testLogger{}))
+		if err != nil {
+			t.Fatalf("MakeClient failed: %v", err)
+		}
+		t.Cleanup(func() { flags.Close() })
+		return flags
+	}
+	first := newClient("flag_a")
+	second := newClient("flag_b")
+	if upstream.loads.Load() != 2 {
+		t.Errorf("Expected loads to be forwarded, got %d", upstream.loads.Load())
+	}
+
+	if err := first.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if upstream.syncs.Load() != 0 {
+		t.Errorf("Expected the sync to be answered by the relay, got %d upstream syncs", upstream.syncs.Load())
+	}
+
+	upstream.enabled.Store(true)
+	upstream.version.Store(2)
+	relay.syncAll(ctx)
+	if upstream.syncs.Load() != 1 {
+		t.Errorf("Expected a single upstream sync for the project, got %d", upstream.syncs.Load())
+	}
+	for _, flags := range []*FeatureFlags{first, second} {
+		if err := flags.Sync(); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+	}
+	if !first.Get("flag_a") || !second.Get("flag_b") {
+		t.Error("Expected both clients to see the relayed state")
+	}
+
+	t.Run("load while the server is down", func(t *testing.T) {
+		upstreamServer.Close()
+		if third := newClient("flag_a"); !third.Get("flag_a") {
+			t.Error("Expected the load to be answered from the relayed state")
+		}
+	})
+}
+
+// Test ServeRelay serves clients on a Unix socket until the context is done
+func TestServeRelayUnix(t *testing.T) {
+	upstream := &relayUpstream{}
+	upstream.version.Store(1)
+	upstream.enabled.Store(true)
+	upstreamServer := httptest.NewServer(upstream)
+	defer upstreamServer.Close()
+
+	path := filepath.Join(t.TempDir(), "relay.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- ServeRelay(ctx, "unix:"+path, upstreamServer.URL, WithLogger(&testLogger{}))
+	}()
+	deadline := time.Now().Add(time.Second)
+	for _, err := os.Stat(path); err != nil && time.Now().Before(deadline); _, err = os.Stat(path) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	flags, err := MakeClient(context.Background(), "unix:"+path, "test-project",
+		Defaults{Flags: []Flag{{Name: "test_flag"}}}, WithRequestCompression(), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+	if !flags.Get("test_flag") {
+		t.Error("Expected test_flag to be enabled through the relay")
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("Expected ServeRelay to return nil, got %v", err)
+	}
+}
+
+// Test the relay reports sync health and project versions
+func TestRelayHealthAndMetrics(t *testing.T) {
+	upstream := &relayUpstream{}
+	upstream.version.Store(4)
+	upstreamServer := httptest.NewServer(upstream)
+	defer upstreamServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	relay, err := NewRelay(ctx, upstreamServer.URL, WithLogger(&testLogger{}), WithSyncInterval(time.Minute))
+	if err != nil {
+		t.Fatalf("NewRelay failed: %v", err)
+	}
+	relayServer := httptest.NewServer(relay)
+	defer relayServer.Close()
+
+	flags, err := MakeClient(ctx, relayServer.URL, "test-project", Defaults{Flags: []Flag{{Name: "flag_a"}}},
+		WithSyncInterval(time.Hour), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		res, err := http.Get(relayServer.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(body)
+	}
+
+	if status, body := get("/healthz"); status != http.StatusOK {
+		t.Errorf("Expected a healthy relay, got %d: %s", status, body)
+	}
+	_, metrics := get("/metrics")
+	for _, want := range []string{
+		"featureflags_relay_projects 1\n",
+		`featureflags_relay_project_version{project="test-project"} 4` + "\n",
+		`featureflags_relay_project_last_sync_timestamp_seconds{project="test-project"} `,
+		"featureflags_relay_sync_errors_total 0\n",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("Expected %q in metrics:\n%s", want, metrics)
+		}
+	}
+
+	// The project has not synced for longer than the stale threshold
+	project, _ := relay.project("test-project")
+	project.mu.Lock()
+	project.synced = time.Now().Add(-relayStaleSyncs*time.Minute - time.Second)
+	project.mu.Unlock()
+	upstreamServer.Close()
+	relay.syncAll(ctx)
+
+	if status, body := get("/healthz"); status != http.StatusServiceUnavailable || !strings.Contains(body, "test-project") {
+		t.Errorf("Expected the stale project to make the relay unhealthy, got %d: %s", status, body)
+	}
+	if _, metrics := get("/metrics"); !strings.Contains(metrics, "featureflags_relay_sync_errors_total 1\n") {
+		t.Errorf("Expected the failed sync to be counted:\n%s", metrics)
+	}
+}
+
+// Test the relay drops idle projects and rejects new ones beyond the limit
+func TestRelayProjectLimits(t *testing.T) {
+	upstream := &relayUpstream{}
+	upstream.version.Store(1)
+	upstreamServer := httptest.NewServer(upstream)
+	defer upstreamServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	relay, err := NewRelay(ctx, upstreamServer.URL, WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatalf("NewRelay failed: %v", err)
+	}
+
+	for i := range maxRelayProjects {
+		if _, ok := relay.project(fmt.Sprintf("project-%d", i)); !ok {
+			t.Fatalf("Expected project %d to be accepted", i)
+		}
+	}
+	rec := httptest.NewRecorder()
+	body := strings.NewReader(`{"project": "one-too-many", "version": 0}`)
+	relay.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/flags/sync", body))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 beyond the project limit, got %d", rec.Code)
+	}
+
+	// A project no client has asked for in a while is dropped by the next sync
+	idle, _ := relay.project("project-0")
+	idle.mu.Lock()
+	idle.requested = time.Now().Add(-relayProjectIdle - time.Minute)
+	idle.mu.Unlock()
+	relay.syncAll(ctx)
+	relay.mu.Lock()
+	_, kept := relay.projects["project-0"]
+	count := len(relay.projects)
+	relay.mu.Unlock()
+	if kept || count != maxRelayProjects-1 {
+		t.Errorf("Expected the idle project to be dropped, %d projects left", count)
+	}
+	if _, ok := relay.project("one-too-many"); !ok {
+		t.Error("Expected a new project to be accepted after an idle one was dropped")
+	}
+}
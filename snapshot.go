@@ -0,0 +1,102 @@
+package featureflags
+
+import "time"
+
+// StateSnapshot is the client state at a single version. It is not affected by later
+// syncs. Taking a snapshot doesn't copy the state, which is immutable once published,
+// so it is cheap enough to take one per request.
+type StateSnapshot struct {
+	Version int
+	// Sequence is the sequence number of the latest ChangeSet included in the snapshot
+	Sequence uint64
+
+	// flags and state evaluate Get and GetValue at the version of the snapshot
+	flags *FeatureFlags
+	state *State
+}
+
+// Snapshot returns the state of all flags and values at a single version. Get and GetValue
+// of the snapshot read that version, so a request which takes a snapshot first sees every
+// flag and value consistently, even if a sync lands while it is handled.
+func (flags *FeatureFlags) Snapshot() StateSnapshot {
+	state := flags.loadState()
+	return StateSnapshot{
+		flags:    flags,
+		state:    state,
+		Version:  state.version,
+		Sequence: state.sequence,
+	}
+}
+
+// Flags returns a copy of all flag states of the snapshot, nil for a zero StateSnapshot
+func (snapshot StateSnapshot) Flags() map[string]FlagState {
+	if snapshot.state == nil {
+		return nil
+	}
+	// Payload slices are shared: the state never modifies them in place
+	flags := make(map[string]FlagState, len(snapshot.state.flagState))
+	for name, flag := range snapshot.state.flagState {
+		flags[name] = flag
+	}
+	return flags
+}
+
+// Values returns a copy of all value states of the snapshot, nil for a zero StateSnapshot
+func (snapshot StateSnapshot) Values() map[string]ValueState {
+	if snapshot.state == nil {
+		return nil
+	}
+	values := make(map[string]ValueState, len(snapshot.state.valueState))
+	for name, value := range snapshot.state.valueState {
+		values[name] = value
+	}
+	return values
+}
+
+// Flag returns the state of the flag in the snapshot without copying the other flags
+func (snapshot StateSnapshot) Flag(name string) (FlagState, bool) {
+	if snapshot.state == nil {
+		return FlagState{}, false
+	}
+	flag, ok := snapshot.state.flagState[name]
+	return flag, ok
+}
+
+// Value returns the state of the value in the snapshot without copying the other values
+func (snapshot StateSnapshot) Value(name string) (ValueState, bool) {
+	if snapshot.state == nil {
+		return ValueState{}, false
+	}
+	value, ok := snapshot.state.valueState[name]
+	return value, ok
+}
+
+// Get returns the state of the flag at the version of the snapshot, it behaves like
+// FeatureFlags.Get. It returns false for a zero StateSnapshot.
+func (snapshot StateSnapshot) Get(name string) (enabled bool) {
+	flags := snapshot.flags
+	if flags == nil {
+		return false
+	}
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationFlag, name, enabled, nil, start) }()
+	}
+
+	return flags.getFlag(snapshot.state, name)
+}
+
+// GetValue returns the value at the version of the snapshot, it behaves like
+// FeatureFlags.GetValue. It returns nil for a zero StateSnapshot.
+func (snapshot StateSnapshot) GetValue(name string) (value interface{}) {
+	flags := snapshot.flags
+	if flags == nil {
+		return nil
+	}
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, value, nil, start) }()
+	}
+
+	return snapshot.state.ValueState(name)
+}
@@ -0,0 +1,99 @@
+package featureflags
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test Snapshot is not affected by updates, and copies of its state by changes of them
+func TestSnapshot(t *testing.T) {
+	flags := &FeatureFlags{}
+	flags.state.Store(&State{
+		version: 3,
+		flagState: map[string]FlagState{
+			"some_flag": {Name: "some_flag", Enabled: true, DefaultEnabled: false},
+		},
+		flagNames: []string{"some_flag"},
+		valueState: map[string]ValueState{
+			"some_value": {Name: "some_value", Value: 20.0, DefaultValue: 10, IsOverridden: true},
+		},
+		valueNames: []string{"some_value"},
+	})
+
+	snapshot := flags.Snapshot()
+	if snapshot.Version != 3 {
+		t.Errorf("Expected version 3, got %d", snapshot.Version)
+	}
+	if !snapshot.Flags()["some_flag"].Enabled {
+		t.Error("Expected some_flag to be enabled in snapshot")
+	}
+	if value := snapshot.Values()["some_value"]; value.Value != 20.0 || !value.IsOverridden {
+		t.Errorf("Unexpected value in snapshot: %+v", value)
+	}
+	if value, ok := snapshot.Value("some_value"); !ok || value.Value != 20.0 {
+		t.Errorf("Unexpected value lookup in snapshot: %+v", value)
+	}
+
+	flags.update(4, []FlagResponse{{Name: "some_flag", Enabled: false}}, nil)
+	if flag, ok := snapshot.Flag("some_flag"); !ok || !flag.Enabled || snapshot.Version != 3 {
+		t.Error("Expected snapshot not to be affected by updates")
+	}
+
+	copied := snapshot.Flags()
+	copied["some_flag"] = FlagState{Name: "some_flag", Enabled: true}
+	if flags.Get("some_flag") || len(snapshot.Flags()) != 1 {
+		t.Error("Expected state not to be affected by snapshot changes")
+	}
+}
+
+// Test Get and GetValue of a snapshot read its version after the state is updated
+func TestSnapshotGet(t *testing.T) {
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flags.state.Store(&State{
+		version:    1,
+		flagState:  map[string]FlagState{"some_flag": {Name: "some_flag", Enabled: true}},
+		flagNames:  []string{"some_flag"},
+		valueState: map[string]ValueState{"some_value": {Name: "some_value", Value: "first"}},
+		valueNames: []string{"some_value"},
+	})
+
+	snapshot := flags.Snapshot()
+	flags.update(2,
+		[]FlagResponse{{Name: "some_flag", Enabled: false}},
+		[]ValueResponse{{Name: "some_value", Value: "second"}})
+
+	if !snapshot.Get("some_flag") || flags.Get("some_flag") {
+		t.Error("Expected the snapshot to keep some_flag enabled while the client has it disabled")
+	}
+	if value := snapshot.GetValue("some_value"); value != "first" {
+		t.Errorf("Expected the value of the snapshot, got %v", value)
+	}
+	if snapshot.Get("unknown_flag") {
+		t.Error("Expected unknown flags to be disabled")
+	}
+
+	var zero StateSnapshot
+	if zero.Get("some_flag") || zero.GetValue("some_value") != nil || zero.Flags() != nil {
+		t.Error("Expected a zero snapshot to return zero values")
+	}
+}
+
+// Test taking a snapshot doesn't copy the state
+func TestSnapshotAllocs(t *testing.T) {
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flagState := make(map[string]FlagState)
+	for i := range 100 {
+		name := fmt.Sprintf("flag_%d", i)
+		flagState[name] = FlagState{Name: name, Enabled: true}
+	}
+	flags.state.Store(&State{version: 1, flagState: flagState})
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if !flags.Snapshot().Get("flag_1") {
+			t.Fatal("Expected flag_1 to be enabled")
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations per snapshot, got %v", allocs)
+	}
+}
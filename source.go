@@ -0,0 +1,99 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+)
+
+// Source provides the state of a project, e.g. from a file or a shared cache.
+// With WithSources, Load and Sync take the state from the first source which succeeds.
+//
+// Precedence of the resolved state is fixed around the chain: local overrides
+// (Override, OverrideValue) win over any source, and defaults apply to flags and
+// values no source has returned.
+type Source interface {
+	Fetch(ctx context.Context, project string) (*LoadFlagsResponse, error)
+}
+
+// SourceFunc is an adapter to use ordinary functions as sources
+type SourceFunc func(ctx context.Context, project string) (*LoadFlagsResponse, error)
+
+func (f SourceFunc) Fetch(ctx context.Context, project string) (*LoadFlagsResponse, error) {
+	return f(ctx, project)
+}
+
+// serverSource stands for the feature flags server the client is created for,
+// the client sends its own load and sync requests for it
+type serverSource struct{}
+
+func (serverSource) Fetch(ctx context.Context, project string) (*LoadFlagsResponse, error) {
+	return nil, errors.New("the server source is only fetched by the client")
+}
+
+// ServerSource is the feature flags server at the address passed to MakeClient.
+// Only states from the server are saved to the StateStore.
+func ServerSource() Source {
+	return serverSource{}
+}
+
+// FileSource reads the state from a JSON file in the load response format, see WithLocalSource
+func FileSource(path string) Source {
+	return &localSource{path: path}
+}
+
+// StoreSource reads the last state saved to the store, e.g. as a fallback after the server
+func StoreSource(store StateStore) Source {
+	return SourceFunc(func(ctx context.Context, project string) (*LoadFlagsResponse, error) {
+		return store.Load(project)
+	})
+}
+
+// hasServer reports whether the sources include the server
+func hasServer(sources []Source) bool {
+	for _, source := range sources {
+		if _, ok := source.(serverSource); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch returns the state from the first source which succeeds, the server only by
+// default. fromServer reports whether the state was sent by the server. load tells
+// whether the server is asked with a load or a sync request.
+func (flags *FeatureFlags) fetch(ctx context.Context, load bool) (res *LoadFlagsResponse, fromServer bool, err error) {
+	sources := flags.sources
+	if len(sources) == 0 {
+		sources = []Source{serverSource{}}
+	}
+
+	var errs []error
+	for _, source := range sources {
+		if _, ok := source.(serverSource); ok {
+			res, err = flags.fetchServer(ctx, load)
+			fromServer = true
+		} else {
+			res, err = source.Fetch(ctx, flags.project)
+			fromServer = false
+		}
+		if err == nil {
+			return res, fromServer, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 1 {
+		return nil, false, errs[0]
+	}
+	return nil, false, errors.Join(errs...)
+}
+
+func (flags *FeatureFlags) fetchServer(ctx context.Context, load bool) (*LoadFlagsResponse, error) {
+	if load && !flags.readOnly {
+		return flags.loadRequest(ctx)
+	}
+	res, err := flags.syncRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &LoadFlagsResponse{Version: res.Version, Flags: res.Flags, Values: res.Values}, nil
+}
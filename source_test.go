@@ -0,0 +1,118 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Test the first source which succeeds provides the state
+func TestSources(t *testing.T) {
+	failing := SourceFunc(func(ctx context.Context, project string) (*LoadFlagsResponse, error) {
+		return nil, errors.New("unavailable")
+	})
+	static := func(enabled bool) Source {
+		return SourceFunc(func(ctx context.Context, project string) (*LoadFlagsResponse, error) {
+			if project != "test-project" {
+				t.Errorf("Expected project test-project, got %s", project)
+			}
+			return &LoadFlagsResponse{Version: 1, Flags: []FlagResponse{{Name: "test_flag", Enabled: enabled}}}, nil
+		})
+	}
+	defaults := Defaults{Flags: []Flag{{Name: "test_flag"}}}
+
+	tests := []struct {
+		name    string
+		sources []Source
+		enabled bool
+		err     bool
+	}{
+		{name: "first source", sources: []Source{static(true), static(false)}, enabled: true},
+		{name: "fallback", sources: []Source{failing, static(true)}, enabled: true},
+		{name: "all failing", sources: []Source{failing, failing}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, err := MakeClient(context.Background(), "", "test-project", defaults,
+				WithSources(tt.sources...), WithLogger(&testLogger{}), WithSyncInterval(time.Hour))
+			if tt.err {
+				if !errors.Is(err, ErrorCantLoadFlags) {
+					t.Errorf("Expected ErrorCantLoadFlags, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MakeClient failed: %v", err)
+			}
+			defer flags.Close()
+
+			if flags.Get("test_flag") != tt.enabled {
+				t.Errorf("Expected test_flag %v", tt.enabled)
+			}
+		})
+	}
+}
+
+type countingStore struct {
+	StateStore
+	saves int
+}
+
+func (s *countingStore) Save(project string, state *LoadFlagsResponse) error {
+	s.saves++
+	return s.StateStore.Save(project, state)
+}
+
+// Test the server in a chain, only its states are saved to the store
+func TestServerSource(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(LoadFlagsResponse{
+			Version: 2,
+			Flags:   []FlagResponse{{Name: "test_flag", Enabled: true}},
+		})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "flags.json")
+	if err := os.WriteFile(path, []byte(`{"version": 1, "flags": [{"name": "test_flag", "enabled": false}]}`), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	store := &countingStore{StateStore: NewFileStateStore(t.TempDir())}
+
+	flags, err := MakeClient(context.Background(), server.URL, "test-project",
+		Defaults{Flags: []Flag{{Name: "test_flag"}}},
+		WithSources(ServerSource(), FileSource(path)), WithStateStore(store),
+		WithLogger(&testLogger{}), WithSyncInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	if !flags.Get("test_flag") || store.saves != 1 {
+		t.Fatalf("Expected the server state to be used and saved, got %v and %d saves", flags.Get("test_flag"), store.saves)
+	}
+
+	fail.Store(true)
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if flags.Get("test_flag") || flags.Version() != 1 {
+		t.Errorf("Expected the file state after the server failed")
+	}
+	if store.saves != 1 {
+		t.Errorf("Expected the file state not to be saved, got %d saves", store.saves)
+	}
+}
@@ -0,0 +1,72 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StateStore persists the last successfully loaded project state, so the client
+// can bootstrap from it when the feature flags server is unreachable on startup.
+type StateStore interface {
+	// Save stores the state of the project, replacing any previous state
+	Save(project string, state *LoadFlagsResponse) error
+	// Load returns the last saved state of the project
+	Load(project string) (*LoadFlagsResponse, error)
+}
+
+// fileStateStore keeps one JSON file per project in a directory
+type fileStateStore struct {
+	dir string
+}
+
+// NewFileStateStore returns a StateStore that keeps state as JSON files in dir.
+func NewFileStateStore(dir string) StateStore {
+	return &fileStateStore{dir: dir}
+}
+
+func (store *fileStateStore) path(project string) string {
+	name := strings.ReplaceAll(project, string(filepath.Separator), "_")
+	return filepath.Join(store.dir, name+".json")
+}
+
+func (store *fileStateStore) Save(project string, state *LoadFlagsResponse) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(store.dir, 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so a crash never leaves a truncated cache
+	tmp, err := os.CreateTemp(store.dir, ".featureflags-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), store.path(project))
+}
+
+func (store *fileStateStore) Load(project string) (*LoadFlagsResponse, error) {
+	data, err := os.ReadFile(store.path(project))
+	if err != nil {
+		return nil, err
+	}
+
+	var state LoadFlagsResponse
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
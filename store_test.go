@@ -0,0 +1,101 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test fileStateStore round trip
+func TestFileStateStore(t *testing.T) {
+	store := NewFileStateStore(t.TempDir())
+
+	t.Run("load missing project", func(t *testing.T) {
+		if _, err := store.Load("missing"); err == nil {
+			t.Error("Expected error for missing project")
+		}
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		state := &LoadFlagsResponse{
+			Version: 3,
+			Flags:   []FlagResponse{{Name: "cached_flag", Enabled: true}},
+			Values:  []ValueResponse{{Name: "cached_value", Value: "cached"}},
+		}
+		if err := store.Save("test.project", state); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		loaded, err := store.Load("test.project")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if loaded.Version != 3 {
+			t.Errorf("Expected version 3, got %d", loaded.Version)
+		}
+		if len(loaded.Flags) != 1 || !loaded.Flags[0].Enabled {
+			t.Errorf("Expected cached_flag to be enabled, got %v", loaded.Flags)
+		}
+	})
+}
+
+// Test MakeClient bootstraps from the state cache when the server is unreachable
+func TestStateCache(t *testing.T) {
+	dir := t.TempDir()
+	defaults := Defaults{
+		Flags: []Flag{{Name: "cached_flag", Enabled: false}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := LoadFlagsResponse{
+			Version: 7,
+			Flags:   []FlagResponse{{Name: "cached_flag", Enabled: true}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+
+	client, err := MakeClient(context.Background(), server.URL, "test-project", defaults, WithStateCache(dir))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	client.Close()
+	server.Close()
+
+	t.Run("bootstrap from cache", func(t *testing.T) {
+		client, err := MakeClient(
+			context.Background(),
+			server.URL,
+			"test-project",
+			defaults,
+			WithStateCache(dir),
+			WithLogger(&testLogger{}),
+		)
+		if err != nil {
+			t.Fatalf("Expected MakeClient to use cached state, got: %v", err)
+		}
+		defer client.Close()
+
+		if !client.Get("cached_flag") {
+			t.Error("Expected cached_flag to be enabled from cache")
+		}
+		if client.loadState().version != 7 {
+			t.Errorf("Expected version 7, got %d", client.loadState().version)
+		}
+	})
+
+	t.Run("fail without cache", func(t *testing.T) {
+		_, err := MakeClient(
+			context.Background(),
+			server.URL,
+			"test-project",
+			defaults,
+			WithStateCache(t.TempDir()),
+		)
+		if err == nil {
+			t.Error("Expected error when server is unreachable and cache is empty")
+		}
+	})
+}
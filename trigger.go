@@ -0,0 +1,44 @@
+package featureflags
+
+import "context"
+
+// ForceSync syncs flags right away, out of band of the sync loop, e.g. from an admin
+// endpoint or a test. Like the sync loop it loads flags instead, if the initial Load
+// has failed in fail-open mode. It doesn't wait for long polling.
+func (flags *FeatureFlags) ForceSync(ctx context.Context) error {
+	return flags.syncOnce(ctx)
+}
+
+// TriggerSync wakes up the sync loop to sync before the interval has passed. It doesn't
+// block: triggers received while a sync is pending are merged into it. With long polling,
+// a trigger received while a request is held by the server is handled after it returns.
+func (flags *FeatureFlags) TriggerSync() {
+	select {
+	case flags.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// TriggerCh returns a new channel which wakes up the sync loop like TriggerSync, so external
+// signals can be forwarded to it. Sends are forwarded by a goroutine of the client until
+// the channel is closed or the client is closed; after Close, sends block, so select on
+// the context of the sender as well. The channel is owned by the caller: closing it
+// stops forwarding and doesn't affect the sync loop.
+func (flags *FeatureFlags) TriggerCh() chan<- struct{} {
+	ch := make(chan struct{})
+	done := flags.context().Done()
+	go func() {
+		for {
+			select {
+			case _, ok := <-ch:
+				if !ok {
+					return
+				}
+				flags.TriggerSync()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,86 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Test ForceSync loads flags if the initial load has failed and syncs them afterwards
+func TestForceSync(t *testing.T) {
+	var loads, syncs atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flags/load" {
+			loads.Add(1)
+		} else {
+			syncs.Add(1)
+		}
+		json.NewEncoder(w).Encode(LoadFlagsResponse{Version: 1})
+	}))
+	defer server.Close()
+
+	flags := &FeatureFlags{
+		client:   server.Client(),
+		httpAddr: server.URL,
+		project:  "test-project",
+		logger:   &testLogger{},
+	}
+	flags.needsLoad.Store(true)
+
+	for i := 0; i < 2; i++ {
+		if err := flags.ForceSync(context.Background()); err != nil {
+			t.Fatalf("ForceSync failed: %v", err)
+		}
+	}
+	if loads.Load() != 1 || syncs.Load() != 1 {
+		t.Errorf("Expected 1 load and 1 sync, got %d and %d", loads.Load(), syncs.Load())
+	}
+}
+
+// Test triggers wake up the sync loop before the interval has passed
+func TestTriggerSync(t *testing.T) {
+	syncs := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flags/sync" {
+			syncs <- struct{}{}
+		}
+		json.NewEncoder(w).Encode(LoadFlagsResponse{Version: 1})
+	}))
+	defer server.Close()
+
+	flags, err := MakeClient(context.Background(), server.URL, "test-project", Defaults{},
+		WithLogger(&testLogger{}), WithSyncInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	wait := func() {
+		t.Helper()
+		select {
+		case <-syncs:
+		case <-time.After(time.Second):
+			t.Fatal("Expected a sync")
+		}
+	}
+
+	flags.TriggerSync()
+	wait()
+	trigger := flags.TriggerCh()
+	trigger <- struct{}{}
+	wait()
+
+	// Closing the channel only stops forwarding, the sync loop keeps its interval
+	close(trigger)
+	flags.TriggerSync()
+	wait()
+	select {
+	case <-syncs:
+		t.Error("Expected no syncs after the trigger channel is closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,90 @@
+package featureflags
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// UsageStats is the number of evaluations of a flag or a value and the time of the last one
+type UsageStats struct {
+	Kind         EvaluationKind
+	Name         string
+	Evaluations  uint64
+	LastAccessed time.Time // zero if the flag or value was never evaluated
+}
+
+type usageKey struct {
+	kind EvaluationKind
+	name string
+}
+
+type usageCounter struct {
+	evaluations  atomic.Uint64
+	lastAccessed atomic.Int64 // unix nanoseconds
+}
+
+// usageTracker counts evaluations, it is registered as an evaluation hook by WithUsageStats
+type usageTracker struct {
+	counters sync.Map // usageKey -> *usageCounter
+}
+
+func (u *usageTracker) AfterEvaluation(evaluation Evaluation) {
+	key := usageKey{kind: evaluation.Kind, name: evaluation.Name}
+	counter, ok := u.counters.Load(key)
+	if !ok {
+		counter, _ = u.counters.LoadOrStore(key, new(usageCounter))
+	}
+	counter.(*usageCounter).evaluations.Add(1)
+	counter.(*usageCounter).lastAccessed.Store(time.Now().UnixNano())
+}
+
+func (u *usageTracker) stats(key usageKey) UsageStats {
+	stats := UsageStats{Kind: key.kind, Name: key.name}
+	if counter, ok := u.counters.Load(key); ok {
+		stats.Evaluations = counter.(*usageCounter).evaluations.Load()
+		stats.LastAccessed = time.Unix(0, counter.(*usageCounter).lastAccessed.Load())
+	}
+	return stats
+}
+
+// Stats returns usage of every flag and value since the client was created, or nil
+// unless WithUsageStats is used. Flags and values known to the client come first in
+// their declaration order, including never evaluated ones, so dead flags are listed
+// with zero evaluations. Names which were evaluated but are unknown to the client
+// follow, sorted by name.
+func (flags *FeatureFlags) Stats() []UsageStats {
+	if flags.usage == nil {
+		return nil
+	}
+
+	state := flags.loadState()
+	known := make(map[usageKey]bool, len(state.flagNames)+len(state.valueNames))
+	stats := make([]UsageStats, 0, len(state.flagNames)+len(state.valueNames))
+	for _, name := range state.flagNames {
+		key := usageKey{kind: EvaluationFlag, name: name}
+		known[key] = true
+		stats = append(stats, flags.usage.stats(key))
+	}
+	for _, name := range state.valueNames {
+		key := usageKey{kind: EvaluationValue, name: name}
+		known[key] = true
+		stats = append(stats, flags.usage.stats(key))
+	}
+
+	var unknown []UsageStats
+	flags.usage.counters.Range(func(key, _ any) bool {
+		if !known[key.(usageKey)] {
+			unknown = append(unknown, flags.usage.stats(key.(usageKey)))
+		}
+		return true
+	})
+	sort.Slice(unknown, func(i, j int) bool {
+		if unknown[i].Name != unknown[j].Name {
+			return unknown[i].Name < unknown[j].Name
+		}
+		return unknown[i].Kind < unknown[j].Kind
+	})
+	return append(stats, unknown...)
+}
@@ -0,0 +1,73 @@
+package featureflags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test Stats counts evaluations and lists never evaluated flags and values
+func TestStats(t *testing.T) {
+	defaults := Defaults{
+		Flags:  []Flag{{Name: "used_flag"}, {Name: "dead_flag"}},
+		Values: []Value{{Name: "used_value", Value: 1}},
+	}
+	path := filepath.Join(t.TempDir(), "flags.json")
+	if err := os.WriteFile(path, []byte(`{"version": 1}`), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	flags, err := MakeClient(context.Background(), "", "test-project", defaults,
+		WithLocalSource(path), WithLogger(&testLogger{}), WithUsageStats())
+	if err != nil {
+		t.Fatalf("MakeClient failed: %v", err)
+	}
+	defer flags.Close()
+
+	before := time.Now()
+	flags.Get("used_flag")
+	flags.Get("used_flag")
+	flags.MustGetValueInt("used_value")
+	flags.Get("unknown_flag")
+
+	stats := flags.Stats()
+	want := []struct {
+		kind        EvaluationKind
+		name        string
+		evaluations uint64
+	}{
+		{EvaluationFlag, "used_flag", 2},
+		{EvaluationFlag, "dead_flag", 0},
+		{EvaluationValue, "used_value", 1},
+		{EvaluationFlag, "unknown_flag", 1},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("Expected %d stats, got %+v", len(want), stats)
+	}
+	for i, w := range want {
+		if stats[i].Kind != w.kind || stats[i].Name != w.name || stats[i].Evaluations != w.evaluations {
+			t.Errorf("Expected %s with %d evaluations, got %+v", w.name, w.evaluations, stats[i])
+		}
+		if accessed := !stats[i].LastAccessed.IsZero(); accessed != (w.evaluations > 0) {
+			t.Errorf("Unexpected last access of %s: %v", w.name, stats[i].LastAccessed)
+		}
+		if w.evaluations > 0 && stats[i].LastAccessed.Before(before) {
+			t.Errorf("Expected last access of %s after %v, got %v", w.name, before, stats[i].LastAccessed)
+		}
+	}
+}
+
+// Test Stats is nil unless usage stats are enabled
+func TestStatsDisabled(t *testing.T) {
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flags.state.Store(&State{
+		flagState: map[string]FlagState{"test_flag": {Name: "test_flag"}},
+		flagNames: []string{"test_flag"},
+	})
+	flags.Get("test_flag")
+
+	if stats := flags.Stats(); stats != nil {
+		t.Errorf("Expected nil stats, got %+v", stats)
+	}
+}
@@ -1,94 +1,196 @@
 package featureflags
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
 
 type ValueState struct {
 	Name         string
 	Value        interface{} // current value (from server or default)
 	DefaultValue interface{} // original default value
 	IsOverridden bool        // true if value was set by server
+	ExpiresAt    time.Time   // set for temporary server overrides, zero if permanent
+
+	constraints []Constraint
+
+	// local overrides set by OverrideValue take precedence over the server state
+	overridden bool
+	override   interface{}
+}
+
+// current returns the value, reverting to the default after a temporary override expires
+func (value ValueState) current(clock Clock) interface{} {
+	if value.overridden {
+		return value.override
+	}
+	if expired(clock, value.ExpiresAt) {
+		return value.DefaultValue
+	}
+	return value.Value
 }
 
 func (state *State) ValueState(name string) interface{} {
 	value, foundValue := state.valueState[name]
 
 	if foundValue {
-		return value.Value
+		return value.current(state.clock)
 	}
 	return nil
 }
 
-func (flags *FeatureFlags) GetValue(name string) interface{} {
-	flags.mu.RLock()
-	defer flags.mu.RUnlock()
-	return flags.state.ValueState(name)
+func (flags *FeatureFlags) GetValue(name string) (value interface{}) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, value, nil, start) }()
+	}
+
+	state := flags.loadState()
+	return state.ValueState(name)
+}
+
+// IntCoercion defines how non-integral float values are converted by int getters
+type IntCoercion int
+
+const (
+	IntCoercionTruncate IntCoercion = iota + 1 // 1, 3.99 becomes 3
+	IntCoercionRound                           // 2, 3.99 becomes 4
+	IntCoercionStrict                          // 3, 3.99 is not an int
+)
+
+// toInt casts the value to int. JSON numbers are decoded as float64, so integral
+// floats are always accepted, and non-integral ones are handled by the coercion policy.
+func (flags *FeatureFlags) toInt(name string, value interface{}) (int, bool) {
+	if intVal, ok := value.(int); ok {
+		return intVal, true
+	}
+
+	floatVal, ok := value.(float64)
+	if !ok {
+		return 0, false
+	}
+	if floatVal == math.Trunc(floatVal) {
+		return int(floatVal), true
+	}
+
+	switch flags.intCoercion {
+	case IntCoercionStrict:
+		return 0, false
+	case IntCoercionRound:
+		flags.coerced(name, "rounding", floatVal)
+		return int(math.Round(floatVal)), true
+	default:
+		flags.coerced(name, "truncating", floatVal)
+		return int(floatVal), true
+	}
+}
+
+// coerced counts a conversion of a non-integral float and logs the first one of the value,
+// so hot getters don't flood the log
+func (flags *FeatureFlags) coerced(name, conversion string, value float64) {
+	counter, ok := flags.coercions.Load(name)
+	if !ok {
+		counter, _ = flags.coercions.LoadOrStore(name, new(atomic.Uint64))
+	}
+	if counter.(*atomic.Uint64).Add(1) == 1 {
+		flags.logger.Printf("Value %s is not an integer, %s %v", name, conversion, value)
+	}
+}
+
+// IntCoercions returns the number of reads by int getters which truncated or rounded
+// a non-integral float, by value name, since the client was created. Values which were
+// never coerced are missing, so the result can be exported as a warning metric.
+func (flags *FeatureFlags) IntCoercions() map[string]uint64 {
+	coercions := make(map[string]uint64)
+	flags.coercions.Range(func(name, counter any) bool {
+		coercions[name.(string)] = counter.(*atomic.Uint64).Load()
+		return true
+	})
+	return coercions
+}
+
+// EvaluateAllValues returns all known values from a single version of the state.
+func (flags *FeatureFlags) EvaluateAllValues() map[string]interface{} {
+	state := flags.loadState()
+
+	result := make(map[string]interface{}, len(state.valueState))
+	for name, value := range state.valueState {
+		result[name] = value.current(state.clock)
+	}
+	return result
 }
 
 // GetValueInt returns the value as an int. Returns an error if the value doesn't exist
 // or cannot be cast to int.
-func (flags *FeatureFlags) GetValueInt(name string) (int, error) {
-	flags.mu.RLock()
-	defer flags.mu.RUnlock()
+func (flags *FeatureFlags) GetValueInt(name string) (result int, err error) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, result, err, start) }()
+	}
+
+	state := flags.loadState()
 
-	value := flags.state.ValueState(name)
+	value := state.ValueState(name)
 	if value == nil {
 		return 0, fmt.Errorf("value %s not found", name)
 	}
 
-	// Try to cast to int
-	if intVal, ok := value.(int); ok {
+	if intVal, ok := flags.toInt(name, value); ok {
 		return intVal, nil
 	}
 
-	// Try to cast to float64 (JSON numbers are decoded as float64)
-	if floatVal, ok := value.(float64); ok {
-		return int(floatVal), nil
-	}
-
 	return 0, fmt.Errorf("value %s cannot be cast to int (type: %T)", name, value)
 }
 
 // MustGetValueInt returns the value as an int. If the value cannot be cast to int,
 // it returns the default value. Panics if the value key doesn't exist in the map
 // (which indicates a programming error - asking for a value that was never defined).
-func (flags *FeatureFlags) MustGetValueInt(name string) int {
-	flags.mu.RLock()
-	defer flags.mu.RUnlock()
+func (flags *FeatureFlags) MustGetValueInt(name string) (result int) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, result, nil, start) }()
+	}
+
+	state := flags.loadState()
 
-	valueState, exists := flags.state.valueState[name]
+	valueState, exists := state.valueState[name]
 	if !exists {
-		panic(fmt.Sprintf("value %s was never defined in defaults - this is a programming error", name))
+		flags.undefinedValue("value %s was never defined in defaults - this is a programming error", name)
+		return
 	}
 
-	value := valueState.Value
+	value := valueState.current(state.clock)
 
 	// Try to cast current value to int
-	if intVal, ok := value.(int); ok {
+	if intVal, ok := flags.toInt(name, value); ok {
 		return intVal
 	}
 
-	// Try to cast to float64 (JSON numbers are decoded as float64)
-	if floatVal, ok := value.(float64); ok {
-		return int(floatVal)
-	}
-
 	// Fall back to default value
 	if defaultInt, ok := valueState.DefaultValue.(int); ok {
-		flags.logger.Printf("Value %s cannot be cast to int, using default %d", name, defaultInt)
+		flags.typeMismatch("Value %s cannot be cast to int, using default %d", name, defaultInt)
 		return defaultInt
 	}
 
 	// This should never happen if defaults were properly initialized
-	panic(fmt.Sprintf("value %s has no valid int default - this is a programming error", name))
+	flags.undefinedValue("value %s has no valid int default - this is a programming error", name)
+	return
 }
 
 // GetValueString returns the value as a string. Returns an error if the value doesn't exist
 // or cannot be cast to string.
-func (flags *FeatureFlags) GetValueString(name string) (string, error) {
-	flags.mu.RLock()
-	defer flags.mu.RUnlock()
+func (flags *FeatureFlags) GetValueString(name string) (result string, err error) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, result, err, start) }()
+	}
+
+	state := flags.loadState()
 
-	value := flags.state.ValueState(name)
+	value := state.ValueState(name)
 	if value == nil {
 		return "", fmt.Errorf("value %s not found", name)
 	}
@@ -104,16 +206,21 @@ func (flags *FeatureFlags) GetValueString(name string) (string, error) {
 // MustGetValueString returns the value as a string. If the value cannot be cast to string,
 // it returns the default value. Panics if the value key doesn't exist in the map
 // (which indicates a programming error - asking for a value that was never defined).
-func (flags *FeatureFlags) MustGetValueString(name string) string {
-	flags.mu.RLock()
-	defer flags.mu.RUnlock()
+func (flags *FeatureFlags) MustGetValueString(name string) (result string) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, result, nil, start) }()
+	}
+
+	state := flags.loadState()
 
-	valueState, exists := flags.state.valueState[name]
+	valueState, exists := state.valueState[name]
 	if !exists {
-		panic(fmt.Sprintf("value %s was never defined in defaults - this is a programming error", name))
+		flags.undefinedValue("value %s was never defined in defaults - this is a programming error", name)
+		return
 	}
 
-	value := valueState.Value
+	value := valueState.current(state.clock)
 
 	// Try to cast current value to string
 	if strVal, ok := value.(string); ok {
@@ -122,36 +229,313 @@ func (flags *FeatureFlags) MustGetValueString(name string) string {
 
 	// Fall back to default value
 	if defaultStr, ok := valueState.DefaultValue.(string); ok {
-		flags.logger.Printf("Value %s cannot be cast to string, using default %s", name, defaultStr)
+		flags.typeMismatch("Value %s cannot be cast to string, using default %s", name, defaultStr)
 		return defaultStr
 	}
 
 	// This should never happen if defaults were properly initialized
-	panic(fmt.Sprintf("value %s has no valid string default - this is a programming error", name))
+	flags.undefinedValue("value %s has no valid string default - this is a programming error", name)
+	return
 }
 
-// IsValueOverridden returns true if the value was set by the server, false if it's using the default.
-func (flags *FeatureFlags) IsValueOverridden(name string) bool {
-	flags.mu.RLock()
-	defer flags.mu.RUnlock()
+// GetValueBool returns the value as a bool. Returns an error if the value doesn't exist
+// or cannot be cast to bool.
+func (flags *FeatureFlags) GetValueBool(name string) (result bool, err error) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, result, err, start) }()
+	}
+
+	state := flags.loadState()
 
-	if valueState, exists := flags.state.valueState[name]; exists {
-		return valueState.IsOverridden
+	value := state.ValueState(name)
+	if value == nil {
+		return false, fmt.Errorf("value %s not found", name)
 	}
-	return false
+
+	// Try to cast to bool
+	if boolVal, ok := value.(bool); ok {
+		return boolVal, nil
+	}
+
+	return false, fmt.Errorf("value %s cannot be cast to bool (type: %T)", name, value)
 }
 
-type ValueResponse struct {
-	Name  string      `json:"name"`
-	Value interface{} `json:"value"` // Using interface{} for Any type
+// MustGetValueBool returns the value as a bool. If the value cannot be cast to bool,
+// it returns the default value. Panics if the value key doesn't exist in the map
+// (which indicates a programming error - asking for a value that was never defined).
+func (flags *FeatureFlags) MustGetValueBool(name string) (result bool) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, result, nil, start) }()
+	}
+
+	state := flags.loadState()
+
+	valueState, exists := state.valueState[name]
+	if !exists {
+		flags.undefinedValue("value %s was never defined in defaults - this is a programming error", name)
+		return
+	}
+
+	// Try to cast current value to bool
+	if boolVal, ok := valueState.current(state.clock).(bool); ok {
+		return boolVal
+	}
+
+	// Fall back to default value
+	if defaultBool, ok := valueState.DefaultValue.(bool); ok {
+		flags.typeMismatch("Value %s cannot be cast to bool, using default %t", name, defaultBool)
+		return defaultBool
+	}
+
+	// This should never happen if defaults were properly initialized
+	flags.undefinedValue("value %s has no valid bool default - this is a programming error", name)
+	return
 }
 
-type ValueInput struct {
-	Name  string      `json:"name"`
-	Value interface{} `json:"value"`
+// toFloat64 casts numeric values to float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// GetValueFloat64 returns the value as a float64. Returns an error if the value doesn't exist
+// or cannot be cast to float64. Integer values are converted to float64.
+func (flags *FeatureFlags) GetValueFloat64(name string) (result float64, err error) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, result, err, start) }()
+	}
+
+	state := flags.loadState()
+
+	value := state.ValueState(name)
+	if value == nil {
+		return 0, fmt.Errorf("value %s not found", name)
+	}
+
+	if floatVal, ok := toFloat64(value); ok {
+		return floatVal, nil
+	}
+
+	return 0, fmt.Errorf("value %s cannot be cast to float64 (type: %T)", name, value)
+}
+
+// MustGetValueFloat64 returns the value as a float64. If the value cannot be cast to float64,
+// it returns the default value. Panics if the value key doesn't exist in the map
+// (which indicates a programming error - asking for a value that was never defined).
+func (flags *FeatureFlags) MustGetValueFloat64(name string) (result float64) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, result, nil, start) }()
+	}
+
+	state := flags.loadState()
+
+	valueState, exists := state.valueState[name]
+	if !exists {
+		flags.undefinedValue("value %s was never defined in defaults - this is a programming error", name)
+		return
+	}
+
+	// Try to cast current value to float64
+	if floatVal, ok := toFloat64(valueState.current(state.clock)); ok {
+		return floatVal
+	}
+
+	// Fall back to default value
+	if defaultFloat, ok := toFloat64(valueState.DefaultValue); ok {
+		flags.typeMismatch("Value %s cannot be cast to float64, using default %v", name, defaultFloat)
+		return defaultFloat
+	}
+
+	// This should never happen if defaults were properly initialized
+	flags.undefinedValue("value %s has no valid float64 default - this is a programming error", name)
+	return
+}
+
+// toDuration parses duration strings like "30s" or "5m", and treats numbers as milliseconds.
+// Negative durations are rejected.
+func toDuration(value interface{}) (time.Duration, bool) {
+	var duration time.Duration
+	switch v := value.(type) {
+	case time.Duration:
+		duration = v
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, false
+		}
+		duration = parsed
+	default:
+		millis, ok := toFloat64(value)
+		if !ok {
+			return 0, false
+		}
+		duration = time.Duration(millis * float64(time.Millisecond))
+	}
+
+	if duration < 0 {
+		return 0, false
+	}
+	return duration, true
+}
+
+// GetValueDuration returns the value as a time.Duration. Strings are parsed with
+// time.ParseDuration ("30s", "5m"), numbers are treated as milliseconds. Returns an error
+// if the value doesn't exist, cannot be parsed or is negative.
+func (flags *FeatureFlags) GetValueDuration(name string) (result time.Duration, err error) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, result, err, start) }()
+	}
+
+	state := flags.loadState()
+
+	value := state.ValueState(name)
+	if value == nil {
+		return 0, fmt.Errorf("value %s not found", name)
+	}
+
+	if duration, ok := toDuration(value); ok {
+		return duration, nil
+	}
+
+	return 0, fmt.Errorf("value %s cannot be cast to duration (value: %v)", name, value)
+}
+
+// MustGetValueDuration returns the value as a time.Duration. If the value cannot be parsed
+// as a duration, it returns the default value. Panics if the value key doesn't exist in the map
+// (which indicates a programming error - asking for a value that was never defined).
+func (flags *FeatureFlags) MustGetValueDuration(name string) (result time.Duration) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, result, nil, start) }()
+	}
+
+	state := flags.loadState()
+
+	valueState, exists := state.valueState[name]
+	if !exists {
+		flags.undefinedValue("value %s was never defined in defaults - this is a programming error", name)
+		return
+	}
+
+	// Try to parse current value as duration
+	if duration, ok := toDuration(valueState.current(state.clock)); ok {
+		return duration
+	}
+
+	// Fall back to default value
+	if defaultDuration, ok := toDuration(valueState.DefaultValue); ok {
+		flags.typeMismatch("Value %s cannot be cast to duration, using default %s", name, defaultDuration)
+		return defaultDuration
+	}
+
+	// This should never happen if defaults were properly initialized
+	flags.undefinedValue("value %s has no valid duration default - this is a programming error", name)
+	return
+}
+
+// UnmarshalValue decodes a JSON value into out, enabling structured configuration objects.
+// String values are treated as raw JSON documents, other values (maps, slices, numbers)
+// are re-encoded as JSON first. Returns an error if the value doesn't exist or cannot
+// be decoded into out.
+func (flags *FeatureFlags) UnmarshalValue(name string, out any) (err error) {
+	if len(flags.hooks) > 0 {
+		start := time.Now()
+		defer func() { flags.evaluated(EvaluationValue, name, out, err, start) }()
+	}
+
+	state := flags.loadState()
+	value := state.ValueState(name)
+
+	if value == nil {
+		return fmt.Errorf("value %s not found", name)
+	}
+
+	var data []byte
+	if strVal, ok := value.(string); ok {
+		data = []byte(strVal)
+	} else if data, err = json.Marshal(value); err != nil {
+		return fmt.Errorf("value %s cannot be encoded as JSON: %w", name, err)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("value %s cannot be decoded into %T: %w", name, out, err)
+	}
+	return nil
+}
+
+// GetValueIntOr returns the value as an int, or the fallback if the value doesn't exist
+// or cannot be cast to int. Unlike MustGetValueInt, it never panics, and missing values
+// and type mismatches are not logged. Non-integral floats follow WithIntCoercion like in
+// GetValueInt, so the first coercion of a value is logged.
+func (flags *FeatureFlags) GetValueIntOr(name string, fallback int) int {
+	if result, err := flags.GetValueInt(name); err == nil {
+		return result
+	}
+	return fallback
+}
+
+// GetValueStringOr returns the value as a string, or the fallback, like GetValueIntOr
+func (flags *FeatureFlags) GetValueStringOr(name string, fallback string) string {
+	if result, err := flags.GetValueString(name); err == nil {
+		return result
+	}
+	return fallback
+}
+
+// GetValueBoolOr returns the value as a bool, or the fallback, like GetValueIntOr
+func (flags *FeatureFlags) GetValueBoolOr(name string, fallback bool) bool {
+	if result, err := flags.GetValueBool(name); err == nil {
+		return result
+	}
+	return fallback
+}
+
+// GetValueFloat64Or returns the value as a float64, or the fallback, like GetValueIntOr
+func (flags *FeatureFlags) GetValueFloat64Or(name string, fallback float64) float64 {
+	if result, err := flags.GetValueFloat64(name); err == nil {
+		return result
+	}
+	return fallback
+}
+
+// GetValueDurationOr returns the value as a time.Duration, or the fallback, like GetValueIntOr
+func (flags *FeatureFlags) GetValueDurationOr(name string, fallback time.Duration) time.Duration {
+	if result, err := flags.GetValueDuration(name); err == nil {
+		return result
+	}
+	return fallback
+}
+
+// IsValueOverridden returns true if the value was set by the server or OverrideValue,
+// false if it's using the default.
+func (flags *FeatureFlags) IsValueOverridden(name string) bool {
+	state := flags.loadState()
+
+	if valueState, exists := state.valueState[name]; exists {
+		return valueState.overridden || valueState.IsOverridden && !expired(state.clock, valueState.ExpiresAt)
+	}
+	return false
 }
 
 type Value struct {
 	Name  string      `json:"name"`
 	Value interface{} `json:"value"` // Using interface{} for Any type
+
+	// Constraints reject invalid values set on the server, see IntValue
+	Constraints []Constraint `json:"-"`
 }
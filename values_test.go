@@ -1,18 +1,20 @@
 package featureflags
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 // Test GetValue method
 func TestGetValue(t *testing.T) {
-	flags := &FeatureFlags{
-		state: State{
-			valueState: map[string]ValueState{
-				"string_value": {Name: "string_value", Value: "hello", DefaultValue: "default", IsOverridden: true},
-				"int_value":    {Name: "int_value", Value: 42, DefaultValue: 10, IsOverridden: true},
-				"float_value":  {Name: "float_value", Value: 3.14, DefaultValue: 0.0, IsOverridden: false},
-			},
+	flags := &FeatureFlags{}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"string_value": {Name: "string_value", Value: "hello", DefaultValue: "default", IsOverridden: true},
+			"int_value":    {Name: "int_value", Value: 42, DefaultValue: 10, IsOverridden: true},
+			"float_value":  {Name: "float_value", Value: 3.14, DefaultValue: 0.0, IsOverridden: false},
 		},
-	}
+	})
 
 	t.Run("get string value", func(t *testing.T) {
 		val := flags.GetValue("string_value")
@@ -36,20 +38,36 @@ func TestGetValue(t *testing.T) {
 	})
 }
 
+// Test EvaluateAllValues returns all values
+func TestEvaluateAllValues(t *testing.T) {
+	flags := &FeatureFlags{}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"string_value": {Name: "string_value", Value: "hello", DefaultValue: "default", IsOverridden: true},
+			"int_value":    {Name: "int_value", Value: 42, DefaultValue: 10},
+		},
+	})
+
+	result := flags.EvaluateAllValues()
+	if len(result) != 2 || result["string_value"] != "hello" || result["int_value"] != 42 {
+		t.Errorf("Unexpected values: %v", result)
+	}
+}
+
 // Test GetValueInt and GetValueString
 func TestGetValueIntAndString(t *testing.T) {
 	logger := &testLogger{}
 	flags := &FeatureFlags{
 		logger: logger,
-		state: State{
-			valueState: map[string]ValueState{
-				"int_value":    {Name: "int_value", Value: 42, DefaultValue: 10, IsOverridden: true},
-				"float_value":  {Name: "float_value", Value: 3.14, DefaultValue: 1.0, IsOverridden: true},
-				"string_value": {Name: "string_value", Value: "hello", DefaultValue: "default", IsOverridden: true},
-				"wrong_type":   {Name: "wrong_type", Value: "not_an_int", DefaultValue: 5, IsOverridden: true},
-			},
-		},
 	}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"int_value":    {Name: "int_value", Value: 42, DefaultValue: 10, IsOverridden: true},
+			"float_value":  {Name: "float_value", Value: 3.14, DefaultValue: 1.0, IsOverridden: true},
+			"string_value": {Name: "string_value", Value: "hello", DefaultValue: "default", IsOverridden: true},
+			"wrong_type":   {Name: "wrong_type", Value: "not_an_int", DefaultValue: 5, IsOverridden: true},
+		},
+	})
 
 	t.Run("GetValueInt - success with int", func(t *testing.T) {
 		val, err := flags.GetValueInt("int_value")
@@ -115,16 +133,16 @@ func TestMustGetValueIntAndString(t *testing.T) {
 	logger := &testLogger{}
 	flags := &FeatureFlags{
 		logger: logger,
-		state: State{
-			valueState: map[string]ValueState{
-				"int_value":      {Name: "int_value", Value: 42, DefaultValue: 10, IsOverridden: true},
-				"float_value":    {Name: "float_value", Value: 3.14, DefaultValue: 1.0, IsOverridden: true},
-				"string_value":   {Name: "string_value", Value: "hello", DefaultValue: "default", IsOverridden: true},
-				"wrong_type_int": {Name: "wrong_type_int", Value: "not_an_int", DefaultValue: 99, IsOverridden: true},
-				"wrong_type_str": {Name: "wrong_type_str", Value: 123, DefaultValue: "fallback", IsOverridden: true},
-			},
-		},
 	}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"int_value":      {Name: "int_value", Value: 42, DefaultValue: 10, IsOverridden: true},
+			"float_value":    {Name: "float_value", Value: 3.14, DefaultValue: 1.0, IsOverridden: true},
+			"string_value":   {Name: "string_value", Value: "hello", DefaultValue: "default", IsOverridden: true},
+			"wrong_type_int": {Name: "wrong_type_int", Value: "not_an_int", DefaultValue: 99, IsOverridden: true},
+			"wrong_type_str": {Name: "wrong_type_str", Value: 123, DefaultValue: "fallback", IsOverridden: true},
+		},
+	})
 
 	t.Run("MustGetValueInt - success with int", func(t *testing.T) {
 		val := flags.MustGetValueInt("int_value")
@@ -180,17 +198,350 @@ func TestMustGetValueIntAndString(t *testing.T) {
 	})
 }
 
-// Test IsValueOverridden
-func TestIsValueOverridden(t *testing.T) {
+// Test GetValueBool, MustGetValueBool, GetValueFloat64 and MustGetValueFloat64
+func TestValueBoolAndFloat64(t *testing.T) {
+	logger := &testLogger{}
 	flags := &FeatureFlags{
-		state: State{
-			valueState: map[string]ValueState{
-				"overridden": {Name: "overridden", Value: 100, DefaultValue: 50, IsOverridden: true},
-				"default":    {Name: "default", Value: 50, DefaultValue: 50, IsOverridden: false},
-			},
+		logger: logger,
+	}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"bool_value":       {Name: "bool_value", Value: true, DefaultValue: false, IsOverridden: true},
+			"float_value":      {Name: "float_value", Value: 2.5, DefaultValue: 1.0, IsOverridden: true},
+			"int_value":        {Name: "int_value", Value: 3, DefaultValue: 1, IsOverridden: false},
+			"wrong_type_bool":  {Name: "wrong_type_bool", Value: "yes", DefaultValue: true, IsOverridden: true},
+			"wrong_type_float": {Name: "wrong_type_float", Value: "1.5", DefaultValue: 10, IsOverridden: true},
+		},
+	})
+
+	t.Run("GetValueBool - success", func(t *testing.T) {
+		val, err := flags.GetValueBool("bool_value")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if !val {
+			t.Error("Expected true")
+		}
+	})
+
+	t.Run("GetValueBool - error on wrong type", func(t *testing.T) {
+		if _, err := flags.GetValueBool("wrong_type_bool"); err == nil {
+			t.Error("Expected error for wrong type")
+		}
+	})
+
+	t.Run("GetValueBool - error on non-existent", func(t *testing.T) {
+		if _, err := flags.GetValueBool("non_existent"); err == nil {
+			t.Error("Expected error for non-existent value")
+		}
+	})
+
+	t.Run("MustGetValueBool - fallback to default on wrong type", func(t *testing.T) {
+		if !flags.MustGetValueBool("wrong_type_bool") {
+			t.Error("Expected default true")
+		}
+	})
+
+	t.Run("MustGetValueBool - panic on non-existent", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected panic for non-existent value")
+			}
+		}()
+		flags.MustGetValueBool("non_existent")
+	})
+
+	t.Run("GetValueFloat64 - success with float64", func(t *testing.T) {
+		val, err := flags.GetValueFloat64("float_value")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if val != 2.5 {
+			t.Errorf("Expected 2.5, got %v", val)
+		}
+	})
+
+	t.Run("GetValueFloat64 - success with int", func(t *testing.T) {
+		val, err := flags.GetValueFloat64("int_value")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if val != 3 {
+			t.Errorf("Expected 3, got %v", val)
+		}
+	})
+
+	t.Run("GetValueFloat64 - error on wrong type", func(t *testing.T) {
+		if _, err := flags.GetValueFloat64("wrong_type_float"); err == nil {
+			t.Error("Expected error for wrong type")
+		}
+	})
+
+	t.Run("MustGetValueFloat64 - fallback to default on wrong type", func(t *testing.T) {
+		if val := flags.MustGetValueFloat64("wrong_type_float"); val != 10 {
+			t.Errorf("Expected default 10, got %v", val)
+		}
+	})
+
+	t.Run("MustGetValueFloat64 - panic on non-existent", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected panic for non-existent value")
+			}
+		}()
+		flags.MustGetValueFloat64("non_existent")
+	})
+}
+
+// Test fallback getters return the value, or the fallback if it's missing or has a wrong type
+func TestGetValueOr(t *testing.T) {
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"int_value":      {Name: "int_value", Value: 3, DefaultValue: 1},
+			"string_value":   {Name: "string_value", Value: "hi", DefaultValue: ""},
+			"bool_value":     {Name: "bool_value", Value: true, DefaultValue: false},
+			"float_value":    {Name: "float_value", Value: 2.5, DefaultValue: 1.0},
+			"duration_value": {Name: "duration_value", Value: "5s", DefaultValue: "1s"},
 		},
+	})
+
+	if val := flags.GetValueIntOr("int_value", 10); val != 3 {
+		t.Errorf("Expected 3, got %d", val)
+	}
+	if val := flags.GetValueIntOr("string_value", 10); val != 10 {
+		t.Errorf("Expected fallback 10 for a wrong type, got %d", val)
+	}
+	if val := flags.GetValueIntOr("missing", 10); val != 10 {
+		t.Errorf("Expected fallback 10 for a missing value, got %d", val)
+	}
+	if val := flags.GetValueStringOr("string_value", "x"); val != "hi" {
+		t.Errorf("Expected hi, got %s", val)
+	}
+	if val := flags.GetValueStringOr("missing", "x"); val != "x" {
+		t.Errorf("Expected fallback x, got %s", val)
+	}
+	if val := flags.GetValueBoolOr("bool_value", false); !val {
+		t.Error("Expected true")
+	}
+	if val := flags.GetValueBoolOr("missing", true); !val {
+		t.Error("Expected fallback true")
+	}
+	if val := flags.GetValueFloat64Or("float_value", 1.5); val != 2.5 {
+		t.Errorf("Expected 2.5, got %f", val)
+	}
+	if val := flags.GetValueFloat64Or("missing", 1.5); val != 1.5 {
+		t.Errorf("Expected fallback 1.5, got %f", val)
+	}
+	if val := flags.GetValueDurationOr("duration_value", time.Minute); val != 5*time.Second {
+		t.Errorf("Expected 5s, got %s", val)
+	}
+	if val := flags.GetValueDurationOr("missing", time.Minute); val != time.Minute {
+		t.Errorf("Expected fallback 1m, got %s", val)
+	}
+}
+
+// Test int coercion policies for non-integral floats
+func TestIntCoercion(t *testing.T) {
+	tests := []struct {
+		name     string
+		coercion IntCoercion
+		value    float64
+		expected int
+		ok       bool
+		coerced  bool
+	}{
+		{name: "default truncates", value: 3.99, expected: 3, ok: true, coerced: true},
+		{name: "truncate", coercion: IntCoercionTruncate, value: -3.99, expected: -3, ok: true, coerced: true},
+		{name: "round", coercion: IntCoercionRound, value: 3.5, expected: 4, ok: true, coerced: true},
+		{name: "strict", coercion: IntCoercionStrict, value: 3.99, ok: false},
+		{name: "strict integral", coercion: IntCoercionStrict, value: 3, expected: 3, ok: true},
 	}
 
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &testLogger{}
+			flags := &FeatureFlags{
+				logger:      logger,
+				intCoercion: tt.coercion,
+			}
+			flags.state.Store(&State{
+				valueState: map[string]ValueState{
+					"float_value": {Name: "float_value", Value: tt.value, DefaultValue: 10, IsOverridden: true},
+				},
+			})
+
+			val, err := flags.GetValueInt("float_value")
+			if tt.ok && (err != nil || val != tt.expected) {
+				t.Errorf("Expected %d, got %d (err: %v)", tt.expected, val, err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("Expected error, got %d", val)
+			}
+
+			// Must getter falls back to default when the value is rejected
+			expected := tt.expected
+			if !tt.ok {
+				expected = 10
+			}
+			if val := flags.MustGetValueInt("float_value"); val != expected {
+				t.Errorf("Expected %d from MustGetValueInt, got %d", expected, val)
+			}
+
+			// Coercions are counted on every read, but logged once
+			coercions := flags.IntCoercions()
+			if tt.coerced && (coercions["float_value"] != 2 || len(logger.messages) != 1) {
+				t.Errorf("Expected 2 coercions and 1 warning, got %v and %v", coercions, logger.messages)
+			}
+			if !tt.coerced && len(coercions) != 0 {
+				t.Errorf("Expected no coercions, got %v", coercions)
+			}
+		})
+	}
+}
+
+// Test GetValueDuration and MustGetValueDuration
+func TestValueDuration(t *testing.T) {
+	flags := &FeatureFlags{
+		logger: &testLogger{},
+	}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"string_value":   {Name: "string_value", Value: "1m30s", DefaultValue: "30s", IsOverridden: true},
+			"millis_value":   {Name: "millis_value", Value: 1500.0, DefaultValue: 1000, IsOverridden: true},
+			"default_value":  {Name: "default_value", Value: "30s", DefaultValue: "30s", IsOverridden: false},
+			"invalid_value":  {Name: "invalid_value", Value: "soon", DefaultValue: "5s", IsOverridden: true},
+			"negative_value": {Name: "negative_value", Value: "-5s", DefaultValue: 100, IsOverridden: true},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		expected time.Duration
+		ok       bool
+	}{
+		{name: "string_value", expected: 90 * time.Second, ok: true},
+		{name: "millis_value", expected: 1500 * time.Millisecond, ok: true},
+		{name: "default_value", expected: 30 * time.Second, ok: true},
+		{name: "invalid_value", ok: false},
+		{name: "negative_value", ok: false},
+		{name: "non_existent", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run("GetValueDuration - "+tt.name, func(t *testing.T) {
+			val, err := flags.GetValueDuration(tt.name)
+			if tt.ok && (err != nil || val != tt.expected) {
+				t.Errorf("Expected %v, got %v (err: %v)", tt.expected, val, err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("Expected error, got %v", val)
+			}
+		})
+	}
+
+	t.Run("MustGetValueDuration - fallback to default", func(t *testing.T) {
+		if val := flags.MustGetValueDuration("invalid_value"); val != 5*time.Second {
+			t.Errorf("Expected default 5s, got %v", val)
+		}
+		if val := flags.MustGetValueDuration("negative_value"); val != 100*time.Millisecond {
+			t.Errorf("Expected default 100ms, got %v", val)
+		}
+	})
+
+	t.Run("MustGetValueDuration - panic on non-existent", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected panic for non-existent value")
+			}
+		}()
+		flags.MustGetValueDuration("non_existent")
+	})
+}
+
+// Test UnmarshalValue decodes structured values
+func TestUnmarshalValue(t *testing.T) {
+	type retryConfig struct {
+		Attempts int      `json:"attempts"`
+		Codes    []string `json:"codes"`
+	}
+
+	flags := &FeatureFlags{}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"json_string": {Name: "json_string", Value: `{"attempts": 3, "codes": ["503"]}`},
+			"json_map": {Name: "json_map", Value: map[string]interface{}{
+				"attempts": 5.0,
+				"codes":    []interface{}{"502", "504"},
+			}},
+			"json_list":   {Name: "json_list", Value: []interface{}{"a", "b"}},
+			"not_json":    {Name: "not_json", Value: "plain text"},
+			"wrong_shape": {Name: "wrong_shape", Value: 42},
+		},
+	})
+
+	t.Run("raw JSON string", func(t *testing.T) {
+		var config retryConfig
+		if err := flags.UnmarshalValue("json_string", &config); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if config.Attempts != 3 || len(config.Codes) != 1 || config.Codes[0] != "503" {
+			t.Errorf("Unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("decoded map", func(t *testing.T) {
+		var config retryConfig
+		if err := flags.UnmarshalValue("json_map", &config); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if config.Attempts != 5 || len(config.Codes) != 2 {
+			t.Errorf("Unexpected config: %+v", config)
+		}
+	})
+
+	t.Run("decoded list", func(t *testing.T) {
+		var list []string
+		if err := flags.UnmarshalValue("json_list", &list); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(list) != 2 || list[1] != "b" {
+			t.Errorf("Unexpected list: %v", list)
+		}
+	})
+
+	t.Run("error on invalid JSON", func(t *testing.T) {
+		var config retryConfig
+		if err := flags.UnmarshalValue("not_json", &config); err == nil {
+			t.Error("Expected error for invalid JSON")
+		}
+	})
+
+	t.Run("error on wrong shape", func(t *testing.T) {
+		var config retryConfig
+		if err := flags.UnmarshalValue("wrong_shape", &config); err == nil {
+			t.Error("Expected error for wrong shape")
+		}
+	})
+
+	t.Run("error on non-existent", func(t *testing.T) {
+		var config retryConfig
+		if err := flags.UnmarshalValue("non_existent", &config); err == nil {
+			t.Error("Expected error for non-existent value")
+		}
+	})
+}
+
+// Test IsValueOverridden
+func TestIsValueOverridden(t *testing.T) {
+	flags := &FeatureFlags{}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"overridden": {Name: "overridden", Value: 100, DefaultValue: 50, IsOverridden: true},
+			"default":    {Name: "default", Value: 50, DefaultValue: 50, IsOverridden: false},
+		},
+	})
+
 	t.Run("value is overridden", func(t *testing.T) {
 		if !flags.IsValueOverridden("overridden") {
 			t.Error("Expected overridden to be true")
@@ -209,3 +560,104 @@ func TestIsValueOverridden(t *testing.T) {
 		}
 	})
 }
+
+// Test temporary value overrides revert to defaults after expiry
+func TestValueExpiredOverride(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Minute)
+
+	state := State{
+		valueState: map[string]ValueState{
+			"expired_value": {Name: "expired_value", Value: 10, DefaultValue: 10},
+			"active_value":  {Name: "active_value", Value: 10, DefaultValue: 10},
+		},
+	}
+	state.Update(1, nil, []ValueResponse{
+		{Name: "expired_value", Value: 50.0, ExpiresAt: &past},
+		{Name: "active_value", Value: 50.0, ExpiresAt: &future},
+	})
+	flags := &FeatureFlags{logger: &testLogger{}}
+	flags.state.Store(&state)
+
+	if val := flags.MustGetValueInt("expired_value"); val != 10 {
+		t.Errorf("Expected expired_value to revert to default 10, got %d", val)
+	}
+	if flags.IsValueOverridden("expired_value") {
+		t.Error("Expected expired_value not to be overridden")
+	}
+	if val := flags.MustGetValueInt("active_value"); val != 50 {
+		t.Errorf("Expected active_value 50 before expiry, got %d", val)
+	}
+	if !flags.IsValueOverridden("active_value") {
+		t.Error("Expected active_value to be overridden")
+	}
+}
+
+func newEvaluationValues() *FeatureFlags {
+	flags := &FeatureFlags{logger: &defaultLogger{}}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"int_value":      {Name: "int_value", Value: 10.0, DefaultValue: 1, IsOverridden: true},
+			"string_value":   {Name: "string_value", Value: "text", DefaultValue: ""},
+			"bool_value":     {Name: "bool_value", Value: true, DefaultValue: false},
+			"float_value":    {Name: "float_value", Value: 1.5, DefaultValue: 1.0},
+			"duration_value": {Name: "duration_value", Value: "5s", DefaultValue: "1s"},
+		},
+		valueNames: []string{"int_value", "string_value", "bool_value", "float_value", "duration_value"},
+	})
+	return flags
+}
+
+var valueLookups = []struct {
+	name   string
+	lookup func(flags *FeatureFlags)
+}{
+	{"GetValue", func(flags *FeatureFlags) { flags.GetValue("int_value") }},
+	{"GetValueInt", func(flags *FeatureFlags) { flags.GetValueInt("int_value") }},
+	{"MustGetValueInt", func(flags *FeatureFlags) { flags.MustGetValueInt("int_value") }},
+	{"MustGetValueString", func(flags *FeatureFlags) { flags.MustGetValueString("string_value") }},
+	{"MustGetValueBool", func(flags *FeatureFlags) { flags.MustGetValueBool("bool_value") }},
+	{"MustGetValueFloat64", func(flags *FeatureFlags) { flags.MustGetValueFloat64("float_value") }},
+	{"MustGetValueDuration", func(flags *FeatureFlags) { flags.MustGetValueDuration("duration_value") }},
+}
+
+// Benchmark value lookups of every type
+func BenchmarkGetValue(b *testing.B) {
+	flags := newEvaluationValues()
+	for _, lookup := range valueLookups {
+		b.Run(lookup.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				lookup.lookup(flags)
+			}
+		})
+	}
+}
+
+// Test value lookups of matching types don't allocate
+func TestGetValueAllocs(t *testing.T) {
+	flags := newEvaluationValues()
+	for _, lookup := range valueLookups {
+		if allocs := testing.AllocsPerRun(100, func() { lookup.lookup(flags) }); allocs != 0 {
+			t.Errorf("Expected no allocations for %s, got %v", lookup.name, allocs)
+		}
+	}
+}
+
+// Benchmark concurrent value reads
+func BenchmarkMustGetValueIntParallel(b *testing.B) {
+	flags := &FeatureFlags{logger: &defaultLogger{}}
+	flags.state.Store(&State{
+		valueState: map[string]ValueState{
+			"some_value": {Name: "some_value", Value: 10.0, DefaultValue: 1, IsOverridden: true},
+		},
+		valueNames: []string{"some_value"},
+	})
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			flags.MustGetValueInt("some_value")
+		}
+	})
+}
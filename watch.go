@@ -0,0 +1,367 @@
+package featureflags
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FlagChange describes a flag whose state was changed by Load or Sync
+type FlagChange struct {
+	Name     string
+	Enabled  bool
+	Previous bool
+	// Sequence of the ChangeSet this change belongs to
+	Sequence uint64
+}
+
+// ValueChange describes a value whose current value was changed by Load or Sync.
+// Value is nil for values which have disappeared, Previous for ones which have appeared.
+type ValueChange struct {
+	Name     string
+	Value    interface{}
+	Previous interface{}
+	// Sequence of the ChangeSet this change belongs to
+	Sequence uint64
+}
+
+// ChangeSet holds all flag and value changes made by a single Load or Sync.
+// Sequence numbers start at 1 and grow by one with every change set, so consumers
+// can detect missed change sets and reconcile using ChangesSince or Snapshot.
+type ChangeSet struct {
+	Sequence uint64
+	Version  int
+	Time     time.Time // when the change set was published, by the clock of the client
+	Changes  []FlagChange
+	Values   []ValueChange
+	// Names of flags and values which have appeared in or disappeared from the state
+	AddedFlags    []string
+	RemovedFlags  []string
+	AddedValues   []string
+	RemovedValues []string
+}
+
+// empty reports whether the change set has no changes
+func (set ChangeSet) empty() bool {
+	return len(set.Changes) == 0 && len(set.Values) == 0 &&
+		len(set.AddedFlags) == 0 && len(set.RemovedFlags) == 0 &&
+		len(set.AddedValues) == 0 && len(set.RemovedValues) == 0
+}
+
+// diffState returns a change set between two states, without a sequence number. Each state
+// is evaluated as of its publication, so expirations and activation windows which passed in
+// between are changes as well.
+func diffState(previous, current *State) ChangeSet {
+	previousClock, currentClock := previous.publishedClock(), current.publishedClock()
+	set := ChangeSet{
+		Version: current.version,
+		Changes: diffFlags(previous.flagState, current.flagState, previousClock, currentClock),
+		Values:  diffValues(previous.valueState, current.valueState, previousClock, currentClock),
+	}
+	set.AddedFlags, set.RemovedFlags = diffNames(previous.flagState, current.flagState)
+	set.AddedValues, set.RemovedValues = diffNames(previous.valueState, current.valueState)
+	return set
+}
+
+// setSequence numbers the change set and all its changes
+func (set *ChangeSet) setSequence(sequence uint64) {
+	set.Sequence = sequence
+	for i := range set.Changes {
+		set.Changes[i].Sequence = sequence
+	}
+	for i := range set.Values {
+		set.Values[i].Sequence = sequence
+	}
+}
+
+// logAttrs describes the change set for the logger
+func (set ChangeSet) logAttrs() []slog.Attr {
+	attrs := []slog.Attr{slog.Int("version", set.Version), slog.Uint64("sequence", set.Sequence)}
+	if len(set.Changes) > 0 {
+		changes := make([]string, len(set.Changes))
+		for i, change := range set.Changes {
+			changes[i] = fmt.Sprintf("%s=%v->%v", change.Name, change.Previous, change.Enabled)
+		}
+		attrs = append(attrs, slog.Any("flags", changes))
+	}
+	if len(set.Values) > 0 {
+		changes := make([]string, len(set.Values))
+		for i, change := range set.Values {
+			changes[i] = fmt.Sprintf("%s=%v->%v", change.Name, change.Previous, change.Value)
+		}
+		attrs = append(attrs, slog.Any("values", changes))
+	}
+	for _, names := range []struct {
+		key   string
+		names []string
+	}{
+		{"added_flags", set.AddedFlags},
+		{"removed_flags", set.RemovedFlags},
+		{"added_values", set.AddedValues},
+		{"removed_values", set.RemovedValues},
+	} {
+		if len(names.names) > 0 {
+			attrs = append(attrs, slog.Any(names.key, names.names))
+		}
+	}
+	return attrs
+}
+
+// watchers keeps channels and callbacks subscribed to flag changes
+type watchers struct {
+	mu        sync.Mutex
+	nextID    int
+	channels  map[string]map[int]chan FlagChange
+	callbacks map[int]func(set ChangeSet)
+
+	// history keeps up to historySize latest change sets for ChangesSince
+	history     []ChangeSet
+	historySize int
+
+	// pending change sets wait for delivery in sequence order. Only one goroutine delivers
+	// at a time, so callbacks can modify the state: their change sets are queued and
+	// delivered after them, instead of waiting for the delivery in progress.
+	pending    []ChangeSet
+	delivering bool
+}
+
+// enqueue queues the change set for delivery. It must be called with flags.mu held,
+// so change sets are queued in sequence order.
+func (w *watchers) enqueue(set ChangeSet) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, set)
+}
+
+// deliver delivers pending change sets, unless another goroutine is delivering them
+func (w *watchers) deliver() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.delivering {
+		return
+	}
+	w.delivering = true
+	// Let the next publisher deliver if a callback panics
+	defer func() { w.delivering = false }()
+
+	for len(w.pending) > 0 {
+		set := w.pending[0]
+		w.pending = w.pending[1:]
+		w.mu.Unlock()
+		func() {
+			// Relock before the deferred reset, also when a callback panics
+			defer w.mu.Lock()
+			w.notify(set)
+		}()
+	}
+}
+
+// record adds the change set to the history
+func (w *watchers) record(set ChangeSet) {
+	if w.historySize <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.history) == w.historySize {
+		w.history = w.history[1:]
+	}
+	w.history = append(w.history, set)
+}
+
+// Watch returns a channel receiving changes of the named flag and a function to stop watching.
+// The channel holds only the latest change: if the receiver falls behind, older changes are dropped.
+// The channel is closed by the stop function.
+func (flags *FeatureFlags) Watch(name string) (<-chan FlagChange, func()) {
+	w := &flags.watchers
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.channels == nil {
+		w.channels = make(map[string]map[int]chan FlagChange)
+	}
+	if w.channels[name] == nil {
+		w.channels[name] = make(map[int]chan FlagChange)
+	}
+	id := w.nextID
+	w.nextID++
+	ch := make(chan FlagChange, 1)
+	w.channels[name][id] = ch
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			delete(w.channels[name], id)
+			if len(w.channels[name]) == 0 {
+				delete(w.channels, name)
+			}
+			close(ch)
+		})
+	}
+	return ch, stop
+}
+
+// OnChange registers a callback invoked with all flag changes after each Load, Sync or
+// local override that changed at least one flag. Callbacks run synchronously on the
+// goroutine which made the change: the sync goroutine, or the caller of Load, Sync,
+// Override, OverrideValue, ClearOverride or ClearOverrides. Changes made while callbacks
+// run, including changes made by the callbacks, are delivered after them, so the call
+// which made a change can return before its callbacks have run.
+// Callbacks can read flags and make changes, but must not call Close, which waits for the
+// sync goroutine. It returns a function to unregister the callback.
+func (flags *FeatureFlags) OnChange(callback func(changes []FlagChange)) func() {
+	return flags.OnChangeSet(func(set ChangeSet) {
+		if len(set.Changes) > 0 {
+			callback(set.Changes)
+		}
+	})
+}
+
+// OnChangeSet is like OnChange, but the callback receives whole change sets: flag and
+// value changes, added and removed names, and the sequence number and version. It is
+// invoked after each Load, Sync or local override that changed anything. Change sets
+// are delivered in sequence order. Callbacks run like those of OnChange: they must
+// not call Close.
+func (flags *FeatureFlags) OnChangeSet(callback func(set ChangeSet)) func() {
+	w := &flags.watchers
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.callbacks == nil {
+		w.callbacks = make(map[int]func(set ChangeSet))
+	}
+	id := w.nextID
+	w.nextID++
+	w.callbacks[id] = callback
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.callbacks, id)
+	}
+}
+
+// ChangesSince returns change sets with sequence numbers greater than sequence, oldest first.
+// It reports false if some of them are no longer kept in the history (see WithChangeHistory),
+// the consumer should then reconcile using Snapshot.
+func (flags *FeatureFlags) ChangesSince(sequence uint64) ([]ChangeSet, bool) {
+	current := flags.loadState().sequence
+	if sequence >= current {
+		return nil, true
+	}
+
+	w := &flags.watchers
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var sets []ChangeSet
+	for _, set := range w.history {
+		if set.Sequence > sequence && set.Sequence <= current {
+			sets = append(sets, set)
+		}
+	}
+	if len(sets) == 0 || sets[0].Sequence != sequence+1 {
+		return nil, false
+	}
+	return sets, true
+}
+
+// History returns the change sets kept by WithChangeHistory, oldest first: the version,
+// the time and the before and after state of every changed flag and value, including
+// local overrides. It is an audit log of recent state transitions, e.g. to correlate
+// behavior changes with flag flips. It is empty unless WithChangeHistory is used.
+func (flags *FeatureFlags) History() []ChangeSet {
+	w := &flags.watchers
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]ChangeSet(nil), w.history...)
+}
+
+// notify delivers the change set to watch channels and callbacks
+func (w *watchers) notify(set ChangeSet) {
+	if set.empty() {
+		return
+	}
+
+	w.mu.Lock()
+	callbacks := make([]func(set ChangeSet), 0, len(w.callbacks))
+	for _, callback := range w.callbacks {
+		callbacks = append(callbacks, callback)
+	}
+	for _, change := range set.Changes {
+		for _, ch := range w.channels[change.Name] {
+			// Keep only the latest change if the receiver is behind
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- change
+		}
+	}
+	w.mu.Unlock()
+
+	for _, callback := range callbacks {
+		callback(set)
+	}
+}
+
+// diffFlags returns changes between two flag states, sorted by name. Each state is
+// evaluated by its own clock.
+func diffFlags(previous, current map[string]FlagState, previousClock, clock Clock) []FlagChange {
+	var changes []FlagChange
+	for name, flag := range current {
+		if old := previous[name]; old.current(previousClock) != flag.current(clock) {
+			changes = append(changes, FlagChange{Name: name, Enabled: flag.current(clock), Previous: old.current(previousClock)})
+		}
+	}
+	for name, old := range previous {
+		if _, exists := current[name]; !exists && old.current(previousClock) {
+			changes = append(changes, FlagChange{Name: name, Enabled: false, Previous: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// diffValues returns changes between two value states, sorted by name, like diffFlags
+func diffValues(previous, current map[string]ValueState, previousClock, clock Clock) []ValueChange {
+	var changes []ValueChange
+	for name, value := range current {
+		var old interface{}
+		if oldValue, exists := previous[name]; exists {
+			old = oldValue.current(previousClock)
+		}
+		if !reflect.DeepEqual(old, value.current(clock)) {
+			changes = append(changes, ValueChange{Name: name, Value: value.current(clock), Previous: old})
+		}
+	}
+	for name, old := range previous {
+		if _, exists := current[name]; !exists && old.current(previousClock) != nil {
+			changes = append(changes, ValueChange{Name: name, Value: nil, Previous: old.current(previousClock)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// diffNames returns sorted names which were added to and removed from a state map
+func diffNames[T any](previous, current map[string]T) (added, removed []string) {
+	for name := range current {
+		if _, exists := previous[name]; !exists {
+			added = append(added, name)
+		}
+	}
+	for name := range previous {
+		if _, exists := current[name]; !exists {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
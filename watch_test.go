@@ -0,0 +1,288 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newWatchedFlags(t *testing.T, responses *[]SyncFlagsResponse) *FeatureFlags {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := (*responses)[0]
+		*responses = (*responses)[1:]
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	flags := &FeatureFlags{
+		client:   server.Client(),
+		httpAddr: server.URL,
+		project:  "test-project",
+		logger:   &testLogger{},
+	}
+	flags.state.Store(&State{
+		version: 1,
+		flagState: map[string]FlagState{
+			"watched_flag": {Name: "watched_flag", Enabled: false},
+			"other_flag":   {Name: "other_flag", Enabled: false},
+		},
+		flagNames:  []string{"watched_flag", "other_flag"},
+		valueState: make(map[string]ValueState),
+	})
+	return flags
+}
+
+// Test Watch receives changes of a single flag
+func TestWatch(t *testing.T) {
+	responses := []SyncFlagsResponse{
+		{Version: 2, Flags: []FlagResponse{{Name: "watched_flag", Enabled: true}}},
+		{Version: 3, Flags: []FlagResponse{{Name: "other_flag", Enabled: true}}},
+	}
+	flags := newWatchedFlags(t, &responses)
+
+	changes, stop := flags.Watch("watched_flag")
+
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	select {
+	case change := <-changes:
+		if change.Name != "watched_flag" || !change.Enabled || change.Previous {
+			t.Errorf("Unexpected change: %+v", change)
+		}
+	default:
+		t.Fatal("Expected a change for watched_flag")
+	}
+
+	// Changes of other flags are not delivered
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	select {
+	case change := <-changes:
+		t.Errorf("Unexpected change: %+v", change)
+	default:
+	}
+
+	stop()
+	if _, ok := <-changes; ok {
+		t.Error("Expected channel to be closed after stop")
+	}
+	stop()
+}
+
+// Test OnChange callbacks receive all changes of a sync
+func TestOnChange(t *testing.T) {
+	responses := []SyncFlagsResponse{
+		{Version: 2, Flags: []FlagResponse{
+			{Name: "watched_flag", Enabled: true},
+			{Name: "other_flag", Enabled: true},
+		}},
+		{Version: 2},
+		{Version: 3, Flags: []FlagResponse{{Name: "watched_flag", Enabled: false}}},
+	}
+	flags := newWatchedFlags(t, &responses)
+
+	var received [][]FlagChange
+	unregister := flags.OnChange(func(changes []FlagChange) {
+		received = append(received, changes)
+	})
+
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(received) != 1 || len(received[0]) != 2 {
+		t.Fatalf("Expected one callback with 2 changes, got %v", received)
+	}
+	if received[0][0].Name != "other_flag" || received[0][1].Name != "watched_flag" {
+		t.Errorf("Expected changes sorted by name, got %v", received[0])
+	}
+
+	// Same version, no callback
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(received) != 1 {
+		t.Errorf("Expected no callback for unchanged version, got %v", received)
+	}
+
+	unregister()
+	if err := flags.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(received) != 1 {
+		t.Errorf("Expected no callback after unregister, got %v", received)
+	}
+}
+
+// Test change sets are numbered and can be replayed from the history
+func TestChangesSince(t *testing.T) {
+	responses := []SyncFlagsResponse{
+		{Version: 2, Flags: []FlagResponse{{Name: "watched_flag", Enabled: true}}},
+		{Version: 3, Flags: []FlagResponse{{Name: "watched_flag", Enabled: true}}},
+		{Version: 4, Flags: []FlagResponse{{Name: "other_flag", Enabled: true}}},
+		{Version: 5, Flags: []FlagResponse{{Name: "watched_flag", Enabled: false}}},
+	}
+	flags := newWatchedFlags(t, &responses)
+	flags.watchers.historySize = 2
+
+	var received []ChangeSet
+	flags.OnChangeSet(func(set ChangeSet) {
+		received = append(received, set)
+	})
+
+	for range responses {
+		if err := flags.Sync(); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+	}
+
+	// Version 3 changed no flags and has no change set
+	if len(received) != 3 {
+		t.Fatalf("Expected 3 change sets, got %v", received)
+	}
+	for i, set := range received {
+		if set.Sequence != uint64(i+1) {
+			t.Errorf("Expected sequence %d, got %d", i+1, set.Sequence)
+		}
+		if set.Changes[0].Sequence != set.Sequence {
+			t.Errorf("Expected change sequence %d, got %d", set.Sequence, set.Changes[0].Sequence)
+		}
+	}
+	if received[1].Version != 4 {
+		t.Errorf("Expected version 4, got %d", received[1].Version)
+	}
+	if snapshot := flags.Snapshot(); snapshot.Sequence != 3 {
+		t.Errorf("Expected snapshot sequence 3, got %d", snapshot.Sequence)
+	}
+
+	sets, ok := flags.ChangesSince(1)
+	if !ok || len(sets) != 2 || sets[0].Sequence != 2 || sets[1].Sequence != 3 {
+		t.Errorf("Expected change sets 2 and 3, got %v (ok: %v)", sets, ok)
+	}
+	if sets, ok := flags.ChangesSince(3); !ok || len(sets) != 0 {
+		t.Errorf("Expected no change sets, got %v (ok: %v)", sets, ok)
+	}
+	// The first change set was evicted from the history
+	if _, ok := flags.ChangesSince(0); ok {
+		t.Error("Expected a gap for evicted change sets")
+	}
+}
+
+// Test History returns recent change sets with their time, including local overrides
+func TestHistory(t *testing.T) {
+	responses := []SyncFlagsResponse{
+		{Version: 2, Flags: []FlagResponse{{Name: "watched_flag", Enabled: true}}},
+		{Version: 3, Flags: []FlagResponse{{Name: "other_flag", Enabled: true}}},
+	}
+	flags := newWatchedFlags(t, &responses)
+	if history := flags.History(); len(history) != 0 {
+		t.Errorf("Expected no history without WithChangeHistory, got %v", history)
+	}
+	flags.watchers.historySize = 2
+
+	clock := &fakeClock{}
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	clock.set(start)
+	flags.state.Store(&State{
+		version:   1,
+		flagState: flags.loadState().flagState,
+		flagNames: flags.loadState().flagNames,
+		clock:     clock,
+	})
+
+	for i := range responses {
+		clock.set(start.Add(time.Duration(i) * time.Minute))
+		if err := flags.Sync(); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+	}
+	clock.set(start.Add(time.Hour))
+	flags.Override("watched_flag", false)
+
+	history := flags.History()
+	if len(history) != 2 {
+		t.Fatalf("Expected the 2 latest change sets, got %v", history)
+	}
+	if set := history[0]; set.Version != 3 || !set.Time.Equal(start.Add(time.Minute)) ||
+		set.Changes[0] != (FlagChange{Name: "other_flag", Enabled: true, Previous: false, Sequence: 2}) {
+		t.Errorf("Unexpected change set of the sync: %+v", set)
+	}
+	if set := history[1]; !set.Time.Equal(start.Add(time.Hour)) ||
+		set.Changes[0] != (FlagChange{Name: "watched_flag", Enabled: false, Previous: true, Sequence: 3}) {
+		t.Errorf("Unexpected change set of the override: %+v", set)
+	}
+}
+
+// Test change sets carry value changes and added and removed names, and are logged
+func TestChangeSetDiff(t *testing.T) {
+	responses := []SyncFlagsResponse{
+		{
+			Version: 2,
+			Flags:   []FlagResponse{{Name: "new_flag", Enabled: false}},
+			Values:  []ValueResponse{{Name: "limit", Value: 10}, {Name: "new_value", Value: "x"}},
+		},
+		{Version: 3},
+	}
+	flags := newWatchedFlags(t, &responses)
+	logger := &printLogger{}
+	flags.logger = logger
+	flags.state.Store(&State{
+		version:    1,
+		flagState:  map[string]FlagState{},
+		valueState: map[string]ValueState{"limit": {Name: "limit", Value: 5, DefaultValue: 5}},
+		valueNames: []string{"limit"},
+	})
+
+	var sets []ChangeSet
+	flags.OnChangeSet(func(set ChangeSet) {
+		sets = append(sets, set)
+	})
+	var flagCallbacks int
+	flags.OnChange(func(changes []FlagChange) {
+		flagCallbacks++
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := flags.Sync(); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+	}
+	if len(sets) != 2 {
+		t.Fatalf("Expected 2 change sets, got %+v", sets)
+	}
+
+	added := sets[0]
+	if len(added.Values) != 2 ||
+		added.Values[0] != (ValueChange{Name: "limit", Value: float64(10), Previous: 5, Sequence: 1}) ||
+		added.Values[1] != (ValueChange{Name: "new_value", Value: "x", Previous: nil, Sequence: 1}) {
+		t.Errorf("Unexpected value changes: %+v", added.Values)
+	}
+	if len(added.AddedFlags) != 1 || added.AddedFlags[0] != "new_flag" ||
+		len(added.AddedValues) != 1 || added.AddedValues[0] != "new_value" {
+		t.Errorf("Unexpected added names: %v and %v", added.AddedFlags, added.AddedValues)
+	}
+
+	removed := sets[1]
+	if len(removed.RemovedFlags) != 1 || removed.RemovedFlags[0] != "new_flag" ||
+		len(removed.RemovedValues) != 1 || removed.RemovedValues[0] != "new_value" {
+		t.Errorf("Unexpected removed names: %v and %v", removed.RemovedFlags, removed.RemovedValues)
+	}
+	if len(removed.Values) != 1 || removed.Values[0].Name != "new_value" || removed.Values[0].Value != nil {
+		t.Errorf("Unexpected value changes: %+v", removed.Values)
+	}
+
+	// No flag was enabled or disabled, so OnChange callbacks are not invoked
+	if flagCallbacks != 0 {
+		t.Errorf("Expected no flag callbacks, got %d", flagCallbacks)
+	}
+
+	want := "Flags state has changed version=2 sequence=1 values=[limit=5->10 new_value=<nil>->x] " +
+		"added_flags=[new_flag] added_values=[new_value]"
+	if len(logger.messages) != 2 || logger.messages[0] != want {
+		t.Errorf("Expected log message %q, got %v", want, logger.messages)
+	}
+}
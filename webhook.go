@@ -0,0 +1,64 @@
+package featureflags
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WebhookSignatureHeader carries the HMAC-SHA256 of the webhook body as "sha256=<hex>"
+const WebhookSignatureHeader = "X-Featureflags-Signature"
+
+// maxWebhookBody limits the size of webhook bodies read for verification
+const maxWebhookBody = 1 << 20
+
+// WebhookHandler returns an http.Handler which the flags server can call on changes.
+// A POST request signed with the shared secret (see WebhookSignatureHeader) triggers
+// an immediate sync, see TriggerSync, and is answered with 204 No Content. Requests
+// with a missing or invalid signature are rejected with 401, as are all requests if
+// the secret is empty.
+func (flags *FeatureFlags) WebhookHandler(secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "can not read body", http.StatusBadRequest)
+			return
+		}
+		if !validWebhookSignature(secret, body, r.Header.Get(WebhookSignatureHeader)) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		flags.TriggerSync()
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// validWebhookSignature checks signature, formatted as "sha256=<hex>", against the body
+func validWebhookSignature(secret string, body []byte, signature string) bool {
+	if secret == "" {
+		return false
+	}
+	sum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sum)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(got, signWebhook(secret, body))
+}
+
+func signWebhook(secret string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
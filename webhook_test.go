@@ -0,0 +1,50 @@
+package featureflags
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test the webhook handler triggers a sync only for signed requests
+func TestWebhookHandler(t *testing.T) {
+	body := `{"project": "test-project", "version": 2}`
+	valid := "sha256=" + hex.EncodeToString(signWebhook("secret", []byte(body)))
+
+	tests := []struct {
+		name      string
+		secret    string
+		method    string
+		signature string
+		status    int
+		triggered bool
+	}{
+		{name: "valid signature", secret: "secret", method: http.MethodPost, signature: valid, status: http.StatusNoContent, triggered: true},
+		{name: "missing signature", secret: "secret", method: http.MethodPost, status: http.StatusUnauthorized},
+		{name: "wrong secret", secret: "other", method: http.MethodPost, signature: valid, status: http.StatusUnauthorized},
+		{name: "malformed signature", secret: "secret", method: http.MethodPost, signature: "sha256=zz", status: http.StatusUnauthorized},
+		{name: "empty secret", secret: "", method: http.MethodPost, signature: valid, status: http.StatusUnauthorized},
+		{name: "wrong method", secret: "secret", method: http.MethodGet, signature: valid, status: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := &FeatureFlags{trigger: make(chan struct{}, 1)}
+			req := httptest.NewRequest(tt.method, "/webhook", strings.NewReader(body))
+			if tt.signature != "" {
+				req.Header.Set(WebhookSignatureHeader, tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			flags.WebhookHandler(tt.secret).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if triggered := len(flags.trigger) == 1; triggered != tt.triggered {
+				t.Errorf("Expected triggered %v, got %v", tt.triggered, triggered)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package featureflags
+
+import "github.com/evo-company/featureflags-go/proto"
+
+// Wire types are defined in the proto package, these aliases keep them available here.
+type (
+	VariableType        = proto.VariableType
+	Variable            = proto.Variable
+	SyncFlagsRequest    = proto.SyncFlagsRequest
+	SyncFlagsResponse   = proto.SyncFlagsResponse
+	LoadFlagsRequest    = proto.LoadFlagsRequest
+	LoadFlagsResponse   = proto.LoadFlagsResponse
+	FlagResponse        = proto.FlagResponse
+	ValueResponse       = proto.ValueResponse
+	ValueInput          = proto.ValueInput
+	ExposureEvent       = proto.ExposureEvent
+	ReportEventsRequest = proto.ReportEventsRequest
+)
+
+const (
+	TypeString    = proto.TypeString
+	TypeNumber    = proto.TypeNumber
+	TypeTimestamp = proto.TypeTimestamp
+	TypeSet       = proto.TypeSet
+)